@@ -0,0 +1,109 @@
+package main
+
+import (
+	"AvitoTech/internal/config"
+	"AvitoTech/internal/infrastructure/postgres"
+	"AvitoTech/pkg/logger"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+const usage = `Использование:
+  migrate [-config file] up        - применить все новые миграции
+  migrate [-config file] down [N]  - откатить N последних миграций (по умолчанию 1)
+  migrate [-config file] version   - показать текущую версию схемы`
+
+// errUsage - неверные аргументы командной строки, завершение с кодом 2
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	configPath := flag.String("config", "", "путь к YAML-файлу конфигурации (по умолчанию CONFIG_FILE)")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Ошибка загрузки конфигурации: ", err)
+	}
+
+	if err := logger.Init(cfg.IsDevelopment()); err != nil {
+		log.Fatal("Не удалось инициализировать логгер: ", err)
+	}
+
+	// os.Exit не выполняет defer, поэтому соединение с БД и блокировка
+	// миграций освобождаются внутри run до выхода
+	code := 0
+	if err := run(cfg, flag.Args()); errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, usage)
+		code = 2
+	} else if err != nil {
+		logger.Log.Error("Ошибка выполнения миграций", zap.Error(err))
+		code = 1
+	}
+
+	logger.Sync()
+	os.Exit(code)
+}
+
+func run(cfg *config.Config, args []string) error {
+	// аргументы проверяются до подключения: с неверной командой usage
+	// выводится сразу, без ожидания БД
+	if len(args) < 1 {
+		return errUsage
+	}
+	steps := 1
+	switch args[0] {
+	case "up", "version":
+	case "down":
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				logger.Log.Error("Некорректное количество шагов", zap.String("steps", args[1]))
+				return errUsage
+			}
+			steps = n
+		}
+	default:
+		return errUsage
+	}
+
+	db, err := postgres.NewDB(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к базе данных: %w", err)
+	}
+	defer db.CloseDB()
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки миграций: %w", err)
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		err = migrator.Down(ctx, steps)
+	}
+	if err != nil {
+		return fmt.Errorf("команда %s: %w", args[0], err)
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения версии схемы: %w", err)
+	}
+	logger.Log.Info("Версия схемы БД",
+		zap.Int64("version", version),
+		zap.Int64("latest", migrator.LatestVersion()),
+	)
+	return nil
+}
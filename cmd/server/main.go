@@ -1,60 +1,247 @@
 package main
 
 import (
+	"AvitoTech/internal/config"
+	"AvitoTech/internal/domain/auth"
+	"AvitoTech/internal/domain/idempotency"
 	"AvitoTech/internal/domain/pr"
 	"AvitoTech/internal/domain/teams"
 	"AvitoTech/internal/domain/user"
 	"AvitoTech/internal/http"
 	"AvitoTech/internal/http/handlers"
 	"AvitoTech/internal/infrastructure/postgres"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
 	"AvitoTech/pkg/logger"
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 func main() {
-	isDev := os.Getenv("ENVIRONMENT") != "production"
-	if err := logger.Init(isDev); err != nil {
+	configPath := flag.String("config", "", "путь к YAML-файлу конфигурации (по умолчанию CONFIG_FILE)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Ошибка загрузки конфигурации: ", err)
+	}
+
+	if err := logger.Init(cfg.IsDevelopment()); err != nil {
 		log.Fatal("Не удалось инициализировать логгер: ", err)
 	}
-	defer logger.Sync()
 
 	logger.Log.Info("Запуск сервиса",
-		zap.Bool("development", isDev),
+		zap.Bool("development", cfg.IsDevelopment()),
 		zap.String("version", "1.0.0"),
 	)
 
-	db, err := postgres.NewDB()
+	// os.Exit не выполняет defer, поэтому всё, что открывается после
+	// подключения к БД, запускается в run и закрывается до выхода
+	if err := run(cfg); err != nil {
+		logger.Log.Error("Сервис остановлен с ошибкой", zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+
+	logger.Log.Info("Сервис остановлен")
+	logger.Sync()
+}
+
+func run(cfg *config.Config) error {
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("ошибка инициализации трассировки: %w", err)
+	}
+	logger.Log.Info("Трассировка", zap.String("exporter", cfg.Tracing.Exporter))
+
+	db, err := postgres.NewDB(cfg.DB)
 	if err != nil {
-		logger.Log.Fatal("Ошибка подключения к базе данных", zap.Error(err))
+		return fmt.Errorf("ошибка подключения к базе данных: %w", err)
+	}
+	defer func() {
+		if err := db.CloseDB(); err != nil {
+			logger.Log.Error("Ошибка при закрытии подключения к БД", zap.Error(err))
+		}
+	}()
+	db.StartMonitor()
+	if err := metrics.Register(metrics.NewDBPoolCollector(db.PoolStats)); err != nil {
+		return fmt.Errorf("ошибка регистрации метрик пула соединений: %w", err)
+	}
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки миграций: %w", err)
+	}
+	if cfg.Migrations.OnStart {
+		if err := migrator.Up(context.Background()); err != nil {
+			return fmt.Errorf("ошибка применения миграций: %w", err)
+		}
+		logger.Log.Info("Схема БД актуальна", zap.Int64("version", migrator.LatestVersion()))
 	}
-	defer db.CloseDB()
 
 	teamRepo := postgres.NewTeamRepo(db)
 	userRepo := postgres.NewUserRepo(db)
 	prRepo := postgres.NewPRRepo(db)
+	tokenRepo := postgres.NewTokenRepo(db)
+	teamLeadRepo := postgres.NewTeamLeadRepo(db)
+	txManager := postgres.NewTxManager(db)
+
+	authService := auth.NewService(tokenRepo, userRepo)
+	if cfg.Auth.BootstrapAdminToken != "" {
+		if err := authService.EnsureBootstrapToken(context.Background(), cfg.Auth.BootstrapAdminToken); err != nil {
+			return fmt.Errorf("ошибка регистрации bootstrap-токена: %w", err)
+		}
+	}
+	if !cfg.Auth.Enabled {
+		logger.Log.Warn("Аутентификация выключена, все запросы выполняются с правами администратора")
+	}
+	tokenHandler := handlers.NewTokenHandler(authService)
+
+	authenticator := auth.NewChain(authService)
+	if jwtCfg := cfg.Auth.JWT; jwtCfg.Enabled {
+		keys, err := auth.NewKeySet(context.Background(), jwtCfg.JWKSFile, jwtCfg.JWKSURL, jwtCfg.RefreshInterval)
+		if err != nil {
+			return fmt.Errorf("ошибка загрузки JWKS: %w", err)
+		}
+		authenticator = append(authenticator, auth.NewJWTAuthenticator(keys, userRepo, auth.JWTConfig{
+			Issuer:     jwtCfg.Issuer,
+			Audience:   jwtCfg.Audience,
+			UserClaim:  jwtCfg.UserClaim,
+			RolesClaim: jwtCfg.RolesClaim,
+			AdminRole:  jwtCfg.AdminRole,
+			Leeway:     jwtCfg.Leeway,
+		}))
+		logger.Log.Info("Включена проверка JWT",
+			zap.String("issuer", jwtCfg.Issuer),
+			zap.String("audience", jwtCfg.Audience),
+		)
+	}
+
+	selectorCfg := pr.SelectorConfig{
+		DefaultStrategy: cfg.Reviewers.Strategy,
+		TeamStrategies:  cfg.Reviewers.TeamStrategies,
+		Weights:         cfg.Reviewers.Weights,
+		MaxOpenReviews:  cfg.Reviewers.MaxOpenReviews,
+	}
+	prService, err := pr.NewService(prRepo, userRepo, teamRepo, txManager, selectorCfg)
+	if err != nil {
+		return fmt.Errorf("ошибка инициализации стратегии выбора ревьюверов: %w", err)
+	}
+	logger.Log.Info("Стратегия выбора ревьюверов",
+		zap.String("default", selectorCfg.DefaultStrategy),
+		zap.Any("teams", selectorCfg.TeamStrategies),
+		zap.Int("max_open_reviews", selectorCfg.MaxOpenReviews),
+	)
 
-	teamService := teams.NewService(teamRepo, userRepo)
+	prHandler := handlers.NewPRHandler(prService)
+
+	teamService := teams.NewService(teamRepo, userRepo, teamLeadRepo, txManager, prService)
 	teamHandler := handlers.NewTeamHandler(teamService)
 
-	userService := user.NewService(userRepo)
+	userService := user.NewService(userRepo, txManager, prService)
 	userHandler := handlers.NewUserHandler(userService)
 
-	prService := pr.NewService(prRepo, userRepo)
-	prHandler := handlers.NewPRHandler(prService)
+	adminHandler := handlers.NewAdminHandler(db, cfg)
+	healthHandler := handlers.NewHealthHandler(
+		handlers.HealthCheck{Name: "postgres", Check: db.CheckConnection},
+		handlers.HealthCheck{Name: "migrations", Check: migrator.CheckMigrations},
+	)
+
+	var rateLimiter, ipRateLimiter *http.RateLimiter
+	if cfg.RateLimit.Enabled {
+		rateLimiter = http.NewRateLimiter(cfg.RateLimit.PerMinute, cfg.RateLimit.Routes)
+		ipRateLimiter = http.NewRateLimiter(cfg.RateLimit.PerIPPerMinute, nil)
+		logger.Log.Info("Ограничение частоты запросов",
+			zap.Int("per_minute", cfg.RateLimit.PerMinute),
+			zap.Int("per_ip_per_minute", cfg.RateLimit.PerIPPerMinute),
+			zap.Any("routes", cfg.RateLimit.Routes),
+		)
+	}
 
-	router := http.NewRouter(teamHandler, userHandler, prHandler)
+	var idempotencyService *idempotency.Service
+	idempotent := http.IdempotencyMiddleware(nil)
+	if cfg.Idempotency.Enabled {
+		idempotencyService = idempotency.NewService(postgres.NewIdempotencyRepo(db), cfg.Idempotency.TTL, cfg.Idempotency.LockTimeout)
+		idempotencyService.StartCleanup()
+		idempotent = http.IdempotencyMiddleware(idempotencyService)
+	}
+
+	router := http.NewRouter(
+		teamHandler,
+		userHandler,
+		prHandler,
+		adminHandler,
+		healthHandler,
+		tokenHandler,
+		http.IPRateLimitMiddleware(ipRateLimiter),
+		http.AuthMiddleware(authenticator, cfg.Auth.Enabled),
+		http.RateLimitMiddleware(rateLimiter),
+		idempotent,
+		auth.NewTeamScope(teamLeadRepo),
+	)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	serverCfg := http.ServerConfig{
+		Addr:              ":" + strconv.Itoa(cfg.HTTP.Port),
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
 	}
+	server := http.NewServer(router, serverCfg)
 
-	logger.Log.Info("Запуск HTTP сервера", zap.String("port", port))
+	logger.Log.Info("Запуск HTTP сервера",
+		zap.String("addr", serverCfg.Addr),
+		zap.Duration("read_timeout", serverCfg.ReadTimeout),
+		zap.Duration("write_timeout", serverCfg.WriteTimeout),
+		zap.Duration("idle_timeout", serverCfg.IdleTimeout),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- http.StartServer(server)
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("ошибка запуска сервера: %w", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// порядок остановки: readiness -> пауза для балансировщика -> HTTP
+	// сервер дожидается текущих запросов -> выгрузка трассировок -> фоновые
+	// проверки и пул БД (закрывается в defer)
+	logger.Log.Info("Получен сигнал остановки, сервис выводится из балансировки",
+		zap.Duration("drain_delay", cfg.Shutdown.DrainDelay),
+	)
+	healthHandler.SetShuttingDown()
+	time.Sleep(cfg.Shutdown.DrainDelay)
+
+	logger.Log.Info("Остановка HTTP сервера", zap.Duration("timeout", cfg.Shutdown.Timeout))
+	if err := http.ShutdownServer(server, cfg.Shutdown.Timeout); err != nil {
+		logger.Log.Error("Не все запросы завершились до остановки сервера", zap.Error(err))
+	}
+
+	tracingCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Log.Error("Ошибка при выгрузке трассировок", zap.Error(err))
+	}
+	cancel()
 
-	if err := http.StartServer(router, ":"+port); err != nil {
-		logger.Log.Fatal("Ошибка запуска сервера", zap.Error(err))
+	if idempotencyService != nil {
+		idempotencyService.StopCleanup()
 	}
+	return nil
 }
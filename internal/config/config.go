@@ -0,0 +1,403 @@
+package config
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Config - итоговая конфигурация сервиса. Значения берутся из значений по
+// умолчанию, затем из YAML-файла (если задан), затем из переменных окружения,
+// указанных в теге env
+type Config struct {
+	Environment string            `yaml:"environment" env:"ENVIRONMENT"`
+	HTTP        HTTPConfig        `yaml:"http"`
+	Shutdown    ShutdownConfig    `yaml:"shutdown"`
+	DB          DBConfig          `yaml:"db"`
+	Migrations  MigrationsConfig  `yaml:"migrations"`
+	Reviewers   ReviewersConfig   `yaml:"reviewers"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+}
+
+type HTTPConfig struct {
+	Port              int           `yaml:"port" env:"PORT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+}
+
+type ShutdownConfig struct {
+	DrainDelay time.Duration `yaml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	Timeout    time.Duration `yaml:"timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type DBConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+
+	MaxConns          int           `yaml:"max_conns" env:"DB_MAX_CONNS"`
+	MinConns          int           `yaml:"min_conns" env:"DB_MIN_CONNS"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"DB_CONNECT_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"DB_CONNECT_MAX_BACKOFF"`
+	PingInterval   time.Duration `yaml:"ping_interval" env:"DB_PING_INTERVAL"`
+}
+
+type MigrationsConfig struct {
+	OnStart bool `yaml:"on_start" env:"MIGRATE_ON_START"`
+}
+
+type ReviewersConfig struct {
+	Strategy       string            `yaml:"strategy" env:"REVIEWER_STRATEGY"`
+	TeamStrategies map[string]string `yaml:"team_strategies" env:"REVIEWER_TEAM_STRATEGIES"`
+	Weights        map[string]int    `yaml:"weights" env:"REVIEWER_WEIGHTS"`
+	// MaxOpenReviews - сколько OPEN PR одновременно может ревьюить один
+	// пользователь, 0 - без лимита; users.max_open_reviews перекрывает его
+	MaxOpenReviews int `yaml:"max_open_reviews" env:"REVIEWER_MAX_OPEN_REVIEWS"`
+}
+
+// Экспортёры трассировок
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingFile   = "file"
+	TracingOTLP   = "otlp"
+)
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	ServiceName  string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	FilePath     string  `yaml:"file_path" env:"TRACING_FILE"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env:"TRACING_OTLP_INSECURE"`
+}
+
+type AuthConfig struct {
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED"`
+	// BootstrapAdminToken регистрируется как админский токен при старте,
+	// чтобы выпустить первые токены через /admin/tokens
+	BootstrapAdminToken string    `yaml:"bootstrap_admin_token" env:"AUTH_BOOTSTRAP_ADMIN_TOKEN" secret:"true"`
+	JWT                 JWTConfig `yaml:"jwt"`
+}
+
+// JWTConfig - проверка JWT внешнего OIDC-провайдера. Ключи берутся из
+// JWKS-файла или по URL (ровно один из двух)
+type JWTConfig struct {
+	Enabled         bool          `yaml:"enabled" env:"JWT_ENABLED"`
+	JWKSFile        string        `yaml:"jwks_file" env:"JWT_JWKS_FILE"`
+	JWKSURL         string        `yaml:"jwks_url" env:"JWT_JWKS_URL"`
+	Issuer          string        `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience        string        `yaml:"audience" env:"JWT_AUDIENCE"`
+	UserClaim       string        `yaml:"user_claim" env:"JWT_USER_CLAIM"`
+	RolesClaim      string        `yaml:"roles_claim" env:"JWT_ROLES_CLAIM"`
+	AdminRole       string        `yaml:"admin_role" env:"JWT_ADMIN_ROLE"`
+	RefreshInterval time.Duration `yaml:"jwks_refresh_interval" env:"JWT_JWKS_REFRESH_INTERVAL"`
+	Leeway          time.Duration `yaml:"leeway" env:"JWT_LEEWAY"`
+}
+
+// RateLimitConfig - лимиты запросов в минуту на API-токен (или IP без
+// аутентификации). Routes задаёт лимиты для отдельных путей, остальные пути
+// делят общий лимит PerMinute. PerIPPerMinute ограничивает все запросы с
+// одного IP ещё до проверки токена
+type RateLimitConfig struct {
+	Enabled        bool           `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	PerMinute      int            `yaml:"per_minute" env:"RATE_LIMIT_PER_MINUTE"`
+	PerIPPerMinute int            `yaml:"per_ip_per_minute" env:"RATE_LIMIT_PER_IP_PER_MINUTE"`
+	Routes         map[string]int `yaml:"routes" env:"RATE_LIMIT_ROUTES"`
+}
+
+// IdempotencyConfig - хранение ответов на POST с заголовком Idempotency-Key.
+// LockTimeout освобождает ключ запроса, который не завершился (например,
+// экземпляр упал), и должен быть больше http.write_timeout
+type IdempotencyConfig struct {
+	Enabled     bool          `yaml:"enabled" env:"IDEMPOTENCY_ENABLED"`
+	TTL         time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
+	LockTimeout time.Duration `yaml:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT"`
+}
+
+var tracingExporters = []string{TracingNone, TracingStdout, TracingFile, TracingOTLP}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+func Default() *Config {
+	return &Config{
+		Environment: EnvDevelopment,
+		HTTP: HTTPConfig{
+			Port:              8080,
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		},
+		Shutdown: ShutdownConfig{
+			DrainDelay: 5 * time.Second,
+			Timeout:    30 * time.Second,
+		},
+		DB: DBConfig{
+			Port:              5432,
+			SSLMode:           "disable",
+			MaxConns:          10,
+			MinConns:          2,
+			HealthCheckPeriod: 30 * time.Second,
+			MaxConnIdleTime:   5 * time.Minute,
+			MaxConnLifetime:   time.Hour,
+			ConnectTimeout:    60 * time.Second,
+			InitialBackoff:    500 * time.Millisecond,
+			MaxBackoff:        10 * time.Second,
+			PingInterval:      5 * time.Second,
+		},
+		Migrations: MigrationsConfig{OnStart: true},
+		Reviewers: ReviewersConfig{
+			Strategy:       dto.StrategyRandom,
+			TeamStrategies: map[string]string{},
+			Weights:        map[string]int{},
+		},
+		Tracing: TracingConfig{
+			Exporter:     TracingNone,
+			ServiceName:  "pr-service",
+			SampleRatio:  1,
+			FilePath:     "traces.json",
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+			JWT: JWTConfig{
+				UserClaim:       "sub",
+				RolesClaim:      "roles",
+				AdminRole:       "admin",
+				RefreshInterval: 10 * time.Minute,
+				Leeway:          30 * time.Second,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:        true,
+			PerMinute:      600,
+			PerIPPerMinute: 1200,
+			Routes:         map[string]int{},
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:     true,
+			TTL:         24 * time.Hour,
+			LockTimeout: time.Minute,
+		},
+	}
+}
+
+// Load собирает конфигурацию и проверяет её. path - путь к YAML-файлу,
+// если пустой, используется переменная CONFIG_FILE; без файла
+// конфигурация берётся только из окружения
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// IsDevelopment - любое окружение, кроме production (staging, local, test и
+// т.п.), считается разработкой
+func (c *Config) IsDevelopment() bool {
+	return c.Environment != EnvProduction
+}
+
+// Validate возвращает все найденные ошибки разом, чтобы их можно было
+// исправить за один перезапуск
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.HTTP.Port < 1 || c.HTTP.Port > 65535 {
+		fail("http.port (PORT)", "must be between 1 and 65535, got %d", c.HTTP.Port)
+	}
+	positive := []struct {
+		field string
+		value time.Duration
+	}{
+		{"http.read_timeout (HTTP_READ_TIMEOUT)", c.HTTP.ReadTimeout},
+		{"http.read_header_timeout (HTTP_READ_HEADER_TIMEOUT)", c.HTTP.ReadHeaderTimeout},
+		{"http.write_timeout (HTTP_WRITE_TIMEOUT)", c.HTTP.WriteTimeout},
+		{"http.idle_timeout (HTTP_IDLE_TIMEOUT)", c.HTTP.IdleTimeout},
+		{"shutdown.timeout (SHUTDOWN_TIMEOUT)", c.Shutdown.Timeout},
+		{"db.health_check_period (DB_HEALTH_CHECK_PERIOD)", c.DB.HealthCheckPeriod},
+		{"db.max_conn_idle_time (DB_MAX_CONN_IDLE_TIME)", c.DB.MaxConnIdleTime},
+		{"db.max_conn_lifetime (DB_MAX_CONN_LIFETIME)", c.DB.MaxConnLifetime},
+		{"db.connect_timeout (DB_CONNECT_TIMEOUT)", c.DB.ConnectTimeout},
+		{"db.initial_backoff (DB_CONNECT_INITIAL_BACKOFF)", c.DB.InitialBackoff},
+		{"db.max_backoff (DB_CONNECT_MAX_BACKOFF)", c.DB.MaxBackoff},
+		{"db.ping_interval (DB_PING_INTERVAL)", c.DB.PingInterval},
+		{"idempotency.ttl (IDEMPOTENCY_TTL)", c.Idempotency.TTL},
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			fail(p.field, "must be a positive duration, got %s", p.value)
+		}
+	}
+	if c.Shutdown.DrainDelay < 0 {
+		fail("shutdown.drain_delay (SHUTDOWN_DRAIN_DELAY)", "must not be negative, got %s", c.Shutdown.DrainDelay)
+	}
+
+	required := []struct {
+		field string
+		value string
+	}{
+		{"db.host (DB_HOST)", c.DB.Host},
+		{"db.user (DB_USER)", c.DB.User},
+		{"db.password (DB_PASSWORD)", c.DB.Password},
+		{"db.name (DB_NAME)", c.DB.Name},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			fail(r.field, "must be set")
+		}
+	}
+	if c.DB.Port < 1 || c.DB.Port > 65535 {
+		fail("db.port (DB_PORT)", "must be between 1 and 65535, got %d", c.DB.Port)
+	}
+	if !slices.Contains(sslModes, c.DB.SSLMode) {
+		fail("db.sslmode (DB_SSLMODE)", "must be one of %v, got %q", sslModes, c.DB.SSLMode)
+	}
+	if c.DB.MaxConns < 1 {
+		fail("db.max_conns (DB_MAX_CONNS)", "must be at least 1, got %d", c.DB.MaxConns)
+	}
+	if c.DB.MinConns < 0 || c.DB.MinConns > c.DB.MaxConns {
+		fail("db.min_conns (DB_MIN_CONNS)", "must be between 0 and max_conns (%d), got %d", c.DB.MaxConns, c.DB.MinConns)
+	}
+	if c.DB.InitialBackoff > c.DB.MaxBackoff {
+		fail("db.initial_backoff (DB_CONNECT_INITIAL_BACKOFF)", "must not exceed max_backoff (%s), got %s", c.DB.MaxBackoff, c.DB.InitialBackoff)
+	}
+
+	if !slices.Contains(dto.Strategies, c.Reviewers.Strategy) {
+		fail("reviewers.strategy (REVIEWER_STRATEGY)", "must be one of %v, got %q", dto.Strategies, c.Reviewers.Strategy)
+	}
+	for teamName, strategy := range c.Reviewers.TeamStrategies {
+		if !slices.Contains(dto.Strategies, strategy) {
+			fail("reviewers.team_strategies (REVIEWER_TEAM_STRATEGIES)", "team %s: must be one of %v, got %q", teamName, dto.Strategies, strategy)
+		}
+	}
+	for userID, weight := range c.Reviewers.Weights {
+		if weight < 0 {
+			fail("reviewers.weights (REVIEWER_WEIGHTS)", "user %s: weight must not be negative, got %d", userID, weight)
+		}
+	}
+	if c.Reviewers.MaxOpenReviews < 0 {
+		fail("reviewers.max_open_reviews (REVIEWER_MAX_OPEN_REVIEWS)", "must not be negative, got %d", c.Reviewers.MaxOpenReviews)
+	}
+
+	if !slices.Contains(tracingExporters, c.Tracing.Exporter) {
+		fail("tracing.exporter (TRACING_EXPORTER)", "must be one of %v, got %q", tracingExporters, c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("tracing.sample_ratio (TRACING_SAMPLE_RATIO)", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+	if c.Tracing.ServiceName == "" {
+		fail("tracing.service_name (OTEL_SERVICE_NAME)", "must be set")
+	}
+	if c.Tracing.Exporter == TracingFile && c.Tracing.FilePath == "" {
+		fail("tracing.file_path (TRACING_FILE)", "must be set for the file exporter")
+	}
+	if c.Tracing.Exporter == TracingOTLP && c.Tracing.OTLPEndpoint == "" {
+		fail("tracing.otlp_endpoint (TRACING_OTLP_ENDPOINT)", "must be set for the otlp exporter")
+	}
+
+	if token := c.Auth.BootstrapAdminToken; token != "" && (!strings.HasPrefix(token, "prs_") || len(token) < 24) {
+		fail("auth.bootstrap_admin_token (AUTH_BOOTSTRAP_ADMIN_TOKEN)", "must start with \"prs_\" and be at least 24 characters long")
+	}
+	if jwtCfg := c.Auth.JWT; jwtCfg.Enabled {
+		if (jwtCfg.JWKSFile == "") == (jwtCfg.JWKSURL == "") {
+			fail("auth.jwt.jwks_file (JWT_JWKS_FILE)", "exactly one of jwks_file and jwks_url (JWT_JWKS_URL) must be set")
+		}
+		if jwtCfg.JWKSURL != "" && !strings.HasPrefix(jwtCfg.JWKSURL, "https://") && !strings.HasPrefix(jwtCfg.JWKSURL, "http://") {
+			fail("auth.jwt.jwks_url (JWT_JWKS_URL)", "must be an http(s) URL, got %q", jwtCfg.JWKSURL)
+		}
+		if jwtCfg.Issuer == "" {
+			fail("auth.jwt.issuer (JWT_ISSUER)", "must be set when JWT is enabled")
+		}
+		if jwtCfg.Audience == "" {
+			fail("auth.jwt.audience (JWT_AUDIENCE)", "must be set when JWT is enabled")
+		}
+		if jwtCfg.UserClaim == "" {
+			fail("auth.jwt.user_claim (JWT_USER_CLAIM)", "must be set")
+		}
+		if jwtCfg.RefreshInterval <= 0 {
+			fail("auth.jwt.jwks_refresh_interval (JWT_JWKS_REFRESH_INTERVAL)", "must be a positive duration, got %s", jwtCfg.RefreshInterval)
+		}
+		if jwtCfg.Leeway < 0 {
+			fail("auth.jwt.leeway (JWT_LEEWAY)", "must not be negative, got %s", jwtCfg.Leeway)
+		}
+	}
+
+	if c.RateLimit.PerMinute < 1 {
+		fail("rate_limit.per_minute (RATE_LIMIT_PER_MINUTE)", "must be at least 1, got %d", c.RateLimit.PerMinute)
+	}
+	if c.RateLimit.PerIPPerMinute < 1 {
+		fail("rate_limit.per_ip_per_minute (RATE_LIMIT_PER_IP_PER_MINUTE)", "must be at least 1, got %d", c.RateLimit.PerIPPerMinute)
+	}
+	for path, limit := range c.RateLimit.Routes {
+		if !strings.HasPrefix(path, "/") {
+			fail("rate_limit.routes (RATE_LIMIT_ROUTES)", "path must start with /, got %q", path)
+		}
+		if limit < 1 {
+			fail("rate_limit.routes (RATE_LIMIT_ROUTES)", "path %s: limit must be at least 1, got %d", path, limit)
+		}
+	}
+
+	if c.Idempotency.LockTimeout <= c.HTTP.WriteTimeout {
+		fail("idempotency.lock_timeout (IDEMPOTENCY_LOCK_TIMEOUT)", "must exceed http.write_timeout (%s), got %s", c.HTTP.WriteTimeout, c.Idempotency.LockTimeout)
+	}
+	if c.Idempotency.TTL < c.Idempotency.LockTimeout {
+		fail("idempotency.ttl (IDEMPOTENCY_TTL)", "must not be shorter than lock_timeout (%s), got %s", c.Idempotency.LockTimeout, c.Idempotency.TTL)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redactedValue = "***"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv перекрывает значения полей, у которых задана переменная из тега env.
+// Словари задаются строкой "key:value,key:value"
+func applyEnv(cfg *Config) error {
+	var errs []error
+	walkFields(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("env")
+		if name == "" {
+			return
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok || raw == "" {
+			return
+		}
+		if err := setFromString(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("environment variable %s: %w", name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+func walkFields(v reflect.Value, fn func(field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && value.Type() != durationType {
+			walkFields(value, fn)
+			continue
+		}
+		fn(field, value)
+	}
+}
+
+func setFromString(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("must be a duration like 5s or 1m, got %q", raw)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", raw)
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", raw)
+		}
+		value.SetBool(b)
+	case reflect.Map:
+		m := reflect.MakeMap(value.Type())
+		for _, item := range strings.Split(raw, ",") {
+			key, val, ok := strings.Cut(strings.TrimSpace(item), ":")
+			key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+			if !ok || key == "" || val == "" {
+				return fmt.Errorf("expected key:value pairs separated by commas, got %q", item)
+			}
+			elem := reflect.New(value.Type().Elem()).Elem()
+			if err := setFromString(elem, val); err != nil {
+				return fmt.Errorf("key %s: %w", key, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(key), elem)
+		}
+		value.Set(m)
+	default:
+		return fmt.Errorf("unsupported field type %s", value.Type())
+	}
+	return nil
+}
+
+// Redacted возвращает действующую конфигурацию для отображения: ключи как в
+// YAML, длительности строками, секреты скрыты
+func (c *Config) Redacted() map[string]any {
+	return redact(reflect.ValueOf(c).Elem())
+}
+
+func redact(v reflect.Value) map[string]any {
+	result := make(map[string]any, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+		switch {
+		case field.Tag.Get("secret") == "true":
+			if value.IsZero() {
+				result[key] = ""
+			} else {
+				result[key] = redactedValue
+			}
+		case value.Type() == durationType:
+			result[key] = time.Duration(value.Int()).String()
+		case value.Kind() == reflect.Struct:
+			result[key] = redact(value)
+		default:
+			result[key] = value.Interface()
+		}
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+	}{
+		{
+			name: "int, string and bool",
+			env:  map[string]string{"PORT": "9090", "REVIEWER_STRATEGY": "round_robin", "RATE_LIMIT_ENABLED": "true"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTP.Port != 9090 || cfg.Reviewers.Strategy != "round_robin" || !cfg.RateLimit.Enabled {
+					t.Errorf("got port %d, strategy %s, rate limit %v", cfg.HTTP.Port, cfg.Reviewers.Strategy, cfg.RateLimit.Enabled)
+				}
+			},
+		},
+		{
+			name: "duration",
+			env:  map[string]string{"HTTP_READ_TIMEOUT": "1m30s"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTP.ReadTimeout != 90*time.Second {
+					t.Errorf("got %s", cfg.HTTP.ReadTimeout)
+				}
+			},
+		},
+		{
+			name: "float",
+			env:  map[string]string{"TRACING_SAMPLE_RATIO": "0.25"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Tracing.SampleRatio != 0.25 {
+					t.Errorf("got %v", cfg.Tracing.SampleRatio)
+				}
+			},
+		},
+		{
+			name: "map replaces the default",
+			env:  map[string]string{"RATE_LIMIT_ROUTES": " /pullRequest/create:60 , /team/add:5"},
+			check: func(t *testing.T, cfg *Config) {
+				want := map[string]int{"/pullRequest/create": 60, "/team/add": 5}
+				if !maps.Equal(cfg.RateLimit.Routes, want) {
+					t.Errorf("got %v, want %v", cfg.RateLimit.Routes, want)
+				}
+			},
+		},
+		{
+			name: "empty value is ignored",
+			env:  map[string]string{"PORT": ""},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.HTTP.Port != Default().HTTP.Port {
+					t.Errorf("got port %d", cfg.HTTP.Port)
+				}
+			},
+		},
+		{name: "bad int", env: map[string]string{"PORT": "80a"}, wantErr: "PORT: must be an integer"},
+		{name: "bad duration", env: map[string]string{"HTTP_READ_TIMEOUT": "10"}, wantErr: "HTTP_READ_TIMEOUT: must be a duration"},
+		{name: "bad bool", env: map[string]string{"RATE_LIMIT_ENABLED": "yes"}, wantErr: "RATE_LIMIT_ENABLED: must be true or false"},
+		{name: "bad map pair", env: map[string]string{"REVIEWER_WEIGHTS": "u1:2,u2"}, wantErr: "REVIEWER_WEIGHTS: expected key:value"},
+		{name: "bad map value", env: map[string]string{"REVIEWER_WEIGHTS": "u1:heavy"}, wantErr: "REVIEWER_WEIGHTS: key u1: must be an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg := Default()
+			err := applyEnv(cfg)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := "http:\n  port: 9000\n  read_timeout: 20s\nreviewers:\n  strategy: weighted\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setRequiredEnv(t)
+	t.Setenv("PORT", "9100")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "env overrides file", got: cfg.HTTP.Port, want: 9100},
+		{name: "file overrides default", got: cfg.HTTP.ReadTimeout, want: 20 * time.Second},
+		{name: "file value without env", got: cfg.Reviewers.Strategy, want: "weighted"},
+		{name: "default without file or env", got: cfg.HTTP.IdleTimeout, want: Default().HTTP.IdleTimeout},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("http:\n  prot: 9000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	setRequiredEnv(t)
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Fatalf("got error %v, want unknown field prot", err)
+	}
+}
+
+// setRequiredEnv задаёт настройки без значений по умолчанию
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	for name, value := range map[string]string{
+		"DB_HOST":     "localhost",
+		"DB_USER":     "postgres",
+		"DB_PASSWORD": "postgres",
+		"DB_NAME":     "pr_service",
+	} {
+		t.Setenv(name, value)
+	}
+}
@@ -0,0 +1,22 @@
+package auth
+
+import "errors"
+
+const (
+	Unauthorized  = "UNAUTHORIZED"
+	Forbidden     = "FORBIDDEN"
+	NotFound      = "NOT_FOUND"
+	BadRequest    = "BAD_REQUEST"
+	InternalError = "INTERNAL_ERROR"
+)
+
+var (
+	ErrUnauthorized  = errors.New("missing or invalid credentials")
+	ErrForbidden     = errors.New("not allowed for this principal")
+	ErrTokenNotFound = errors.New("token not found or already revoked")
+	ErrInvalidToken  = errors.New("invalid token request")
+	ErrUserNotFound  = errors.New("user not found")
+	// ErrScopeNotFound - команду запроса определить не удалось (нет
+	// пользователя, PR или команды); ответ об этом даёт сам обработчик
+	ErrScopeNotFound = errors.New("team scope not found")
+)
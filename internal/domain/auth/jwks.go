@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	jwksFetchTimeout = 5 * time.Second
+	maxJWKSSize      = 1 << 20
+	// minRefreshGap не даёт токенам с выдуманным kid заставлять сервис
+	// перечитывать JWKS на каждый запрос
+	minRefreshGap = 30 * time.Second
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet - открытые ключи из JWKS-файла или URL. Ключи перечитываются, когда
+// устарели или когда пришёл токен с неизвестным kid
+type KeySet struct {
+	file    string
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	// загрузка выполняется без mu: запросы продолжают проверять токены старыми
+	// ключами, а одновременные обновления объединяются в одно
+	group singleflight.Group
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+}
+
+func NewKeySet(ctx context.Context, file, url string, refresh time.Duration) (*KeySet, error) {
+	if (file == "") == (url == "") {
+		return nil, errors.New("exactly one of JWKS file or URL must be set")
+	}
+	ks := &KeySet{
+		file:    file,
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: jwksFetchTimeout},
+	}
+	if err := ks.reload(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Key возвращает ключ по kid. Пустой kid допустим, если в наборе один ключ
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	key, ok := ks.lookup(kid)
+	stale := time.Since(ks.loadedAt) > ks.refresh
+	canRetry := time.Since(ks.attemptedAt) > minRefreshGap
+	ks.mu.RUnlock()
+
+	// с неизвестным kid запрос присоединяется и к уже идущей загрузке, а
+	// частоту новых загрузок ограничивает reloadIfDue
+	if !ok || (stale && canRetry) {
+		// загрузка не зависит от отмены запроса, который её начал: её
+		// результат ждут и другие запросы
+		result := ks.group.DoChan("jwks", func() (any, error) {
+			return nil, ks.reloadIfDue(context.WithoutCancel(ctx))
+		})
+		select {
+		case res := <-result:
+			// при ошибке продолжаем работать со старыми ключами
+			if res.Err == nil {
+				ks.mu.RLock()
+				key, ok = ks.lookup(kid)
+				ks.mu.RUnlock()
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// lookup вызывается под mu
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// reloadIfDue перечитывает ключи, если с прошлой попытки прошло больше
+// minRefreshGap: запрос, дождавшийся завершения чужой загрузки, не должен
+// начинать новую
+func (ks *KeySet) reloadIfDue(ctx context.Context) error {
+	ks.mu.Lock()
+	if time.Since(ks.attemptedAt) <= minRefreshGap {
+		ks.mu.Unlock()
+		return nil
+	}
+	ks.mu.Unlock()
+	return ks.reload(ctx)
+}
+
+func (ks *KeySet) reload(ctx context.Context) error {
+	ks.mu.Lock()
+	ks.attemptedAt = time.Now()
+	ks.mu.Unlock()
+
+	data, err := ks.read(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.loadedAt = time.Now()
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *KeySet) read(ctx context.Context) ([]byte, error) {
+	if ks.file != "" {
+		data, err := os.ReadFile(ks.file)
+		if err != nil {
+			return nil, fmt.Errorf("read JWKS file: %w", err)
+		}
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build JWKS request: %w", err)
+	}
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// parseJWKS берёт RSA-ключи и EC-ключи на P-256, остальные пропускает
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaKey()
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			key, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no supported signing keys (RSA or EC P-256)")
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() < 3 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecKey() (*ecdsa.PublicKey, error) {
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	curve := elliptic.P256()
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("EC point is not on P-256")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// JWTConfig - параметры проверки токенов внешнего OIDC-провайдера
+type JWTConfig struct {
+	Issuer     string
+	Audience   string
+	UserClaim  string
+	RolesClaim string
+	AdminRole  string
+	Leeway     time.Duration
+}
+
+// JWTAuthenticator проверяет JWT, подписанные RS256 или ES256 ключами из
+// JWKS. Пользователь из claim должен существовать в таблице users
+type JWTAuthenticator struct {
+	keys   *KeySet
+	users  interfaces.UserRepository
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(keys *KeySet, users interfaces.UserRepository, cfg JWTConfig) *JWTAuthenticator {
+	// iss и aud проверяются всегда: иначе принимались бы токены, выданные
+	// тем же провайдером другим клиентам, вместе с их ролями
+	return &JWTAuthenticator{
+		keys:  keys,
+		users: users,
+		cfg:   cfg,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, raw string) (*Principal, error) {
+	if strings.Count(raw, ".") != 2 {
+		return nil, ErrUnauthorized
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		logger.FromContext(ctx).Debug("JWT отклонён", zap.Error(err))
+		return nil, ErrUnauthorized
+	}
+
+	userID, _ := claims[a.cfg.UserClaim].(string)
+	role := RoleUser
+	if slices.Contains(claimStrings(claims[a.cfg.RolesClaim]), a.cfg.AdminRole) {
+		role = RoleAdmin
+	}
+
+	if role != RoleAdmin {
+		if userID == "" {
+			logger.FromContext(ctx).Warn("В JWT нет идентификатора пользователя", zap.String("claim", a.cfg.UserClaim))
+			return nil, ErrUnauthorized
+		}
+		if _, err := a.users.GetUser(ctx, userID); err != nil {
+			logger.FromContext(ctx).Warn("Пользователь из JWT не найден",
+				zap.String("user_id", userID),
+				zap.Error(err),
+			)
+			return nil, ErrUnauthorized
+		}
+	}
+
+	// jti не используется как TokenID: у каждого обновлённого токена он новый,
+	// а лимиты и идемпотентность должны относиться к пользователю
+	return &Principal{UserID: userID, Role: role, Method: MethodJWT}, nil
+}
+
+// claimStrings принимает роли как массив строк или строку через пробел
+func claimStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, raw string) (*Principal, error)
+}
+
+// Chain пробует аутентификаторы по очереди; ErrUnauthorized от одного из
+// них передаёт токен следующему, остальные ошибки возвращаются сразу
+type Chain []Authenticator
+
+func NewChain(authenticators ...Authenticator) Chain {
+	return Chain(authenticators)
+}
+
+func (c Chain) Authenticate(ctx context.Context, raw string) (*Principal, error) {
+	for _, a := range c {
+		principal, err := a.Authenticate(ctx, raw)
+		if errors.Is(err, ErrUnauthorized) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("authenticate: %w", err)
+		}
+		return principal, nil
+	}
+	return nil, ErrUnauthorized
+}
@@ -0,0 +1,352 @@
+package auth
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/domain/interfaces"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://idp.example.com"
+	testAudience = "pr-service"
+)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+var (
+	keysOnce  sync.Once
+	generated testKeys
+)
+
+func signingKeys(t *testing.T) testKeys {
+	t.Helper()
+	keysOnce.Do(func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+		generated = testKeys{rsa: rsaKey, ec: ecKey}
+	})
+	return generated
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jwk {
+	return jwk{Kty: "RSA", Kid: kid, Use: "sig", N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jwk {
+	return jwk{Kty: "EC", Kid: kid, Crv: "P-256", X: b64(key.X.FillBytes(make([]byte, 32))), Y: b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+func jwksJSON(t *testing.T, keys ...jwk) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string][]jwk{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys := signingKeys(t)
+	rsaKey := rsaJWK("rsa-1", &keys.rsa.PublicKey)
+	ecKey := ecJWK("ec-1", &keys.ec.PublicKey)
+
+	encKey := rsaJWK("rsa-enc", &keys.rsa.PublicKey)
+	encKey.Use = "enc"
+	p384 := ecJWK("ec-384", &keys.ec.PublicKey)
+	p384.Crv = "P-384"
+	badExponent := rsaJWK("rsa-bad", &keys.rsa.PublicKey)
+	badExponent.E = b64([]byte{1})
+	offCurve := ecJWK("ec-bad", &keys.ec.PublicKey)
+	offCurve.Y = b64([]byte{1})
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantKids []string
+		wantErr  bool
+	}{
+		{name: "rsa and ec", data: jwksJSON(t, rsaKey, ecKey), wantKids: []string{"rsa-1", "ec-1"}},
+		{name: "unsupported keys skipped", data: jwksJSON(t, rsaKey, encKey, p384, jwk{Kty: "oct", Kid: "hmac"}), wantKids: []string{"rsa-1"}},
+		{name: "only unsupported keys", data: jwksJSON(t, encKey, p384), wantErr: true},
+		{name: "bad rsa exponent", data: jwksJSON(t, badExponent), wantErr: true},
+		{name: "ec point off curve", data: jwksJSON(t, offCurve), wantErr: true},
+		{name: "not json", data: []byte("<html>"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJWKS(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %d keys", len(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.wantKids) {
+				t.Errorf("got %d keys, want %v", len(got), tt.wantKids)
+			}
+			for _, kid := range tt.wantKids {
+				if _, ok := got[kid]; !ok {
+					t.Errorf("key %s missing", kid)
+				}
+			}
+		})
+	}
+}
+
+// jwksServer отдаёт текущий набор ключей и считает запросы
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	body     []byte
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, body []byte) *jwksServer {
+	t.Helper()
+	s := &jwksServer{body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, _ = w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) rotate(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func TestKeySetRefresh(t *testing.T) {
+	keys := signingKeys(t)
+	ctx := context.Background()
+
+	server := newJWKSServer(t, jwksJSON(t, rsaJWK("old", &keys.rsa.PublicKey)))
+	ks, err := NewKeySet(ctx, "", server.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	server.rotate(jwksJSON(t, ecJWK("new", &keys.ec.PublicKey)))
+
+	// сразу после загрузки неизвестный kid не вызывает повторный запрос
+	if _, err := ks.Key(ctx, "new"); err == nil {
+		t.Fatal("new key found before refresh gap passed")
+	}
+	if got := server.requests.Load(); got != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", got)
+	}
+
+	ks.mu.Lock()
+	ks.attemptedAt = time.Now().Add(-2 * minRefreshGap)
+	ks.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ks.Key(ctx, "new"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Key after rotation: %v", err)
+	}
+	if got := server.requests.Load(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want concurrent refreshes merged into 1", got-1)
+	}
+
+	if _, err := ks.Key(ctx, "old"); err == nil {
+		t.Error("rotated out key still accepted")
+	}
+}
+
+func TestKeySetKeepsKeysOnFailedRefresh(t *testing.T) {
+	keys := signingKeys(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksJSON(t, rsaJWK("k1", &keys.rsa.PublicKey)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeySet(ctx, path, "", time.Nanosecond)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ks.mu.Lock()
+	ks.attemptedAt = time.Now().Add(-2 * minRefreshGap)
+	ks.mu.Unlock()
+
+	if _, err := ks.Key(ctx, ""); err != nil {
+		t.Errorf("single key lookup after failed refresh: %v", err)
+	}
+}
+
+type usersStub struct {
+	interfaces.UserRepository
+	known map[string]bool
+}
+
+func (u usersStub) GetUser(_ context.Context, userID string) (*dto.UserDTO, error) {
+	if !u.known[userID] {
+		return nil, errors.New("user not found")
+	}
+	return &dto.UserDTO{UserID: userID, IsActive: true}, nil
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	keys := signingKeys(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	data := jwksJSON(t, rsaJWK("rsa-1", &keys.rsa.PublicKey), ecJWK("ec-1", &keys.ec.PublicKey))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeySet(ctx, path, "", time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	authenticator := NewJWTAuthenticator(ks, usersStub{known: map[string]bool{"u1": true}}, JWTConfig{
+		Issuer:     testIssuer,
+		Audience:   testAudience,
+		UserClaim:  "sub",
+		RolesClaim: "roles",
+		AdminRole:  "pr-admin",
+	})
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": testIssuer,
+			"aud": testAudience,
+			"sub": "u1",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	rs256 := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "rsa-1"
+		raw, err := token.SignedString(keys.rsa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	es256 := jwt.NewWithClaims(jwt.SigningMethodES256, valid())
+	es256.Header["kid"] = "ec-1"
+	es256Raw, err := es256.SignedString(keys.ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs256Raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownKid := jwt.NewWithClaims(jwt.SigningMethodRS256, valid())
+	unknownKid.Header["kid"] = "rsa-2"
+	unknownKidRaw, err := unknownKid.SignedString(keys.rsa)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		raw      string
+		wantRole string
+		wantUser string
+	}{
+		{name: "rs256", raw: rs256(valid()), wantRole: RoleUser, wantUser: "u1"},
+		{name: "es256", raw: es256Raw, wantRole: RoleUser, wantUser: "u1"},
+		{name: "admin role in array", raw: rs256(with("roles", []string{"dev", "pr-admin"})), wantRole: RoleAdmin, wantUser: "u1"},
+		{name: "admin role in string", raw: rs256(with("roles", "dev pr-admin")), wantRole: RoleAdmin, wantUser: "u1"},
+		{name: "admin not in users", raw: rs256(jwt.MapClaims{"iss": testIssuer, "aud": testAudience, "sub": "ghost", "roles": "pr-admin", "exp": time.Now().Add(time.Hour).Unix()}), wantRole: RoleAdmin, wantUser: "ghost"},
+		{name: "audience in array", raw: rs256(with("aud", []string{"other", testAudience})), wantRole: RoleUser, wantUser: "u1"},
+		{name: "wrong issuer", raw: rs256(with("iss", "https://evil.example.com"))},
+		{name: "wrong audience", raw: rs256(with("aud", "other-client"))},
+		{name: "no audience", raw: rs256(with("aud", nil))},
+		{name: "expired", raw: rs256(with("exp", time.Now().Add(-time.Hour).Unix()))},
+		{name: "no expiry", raw: rs256(with("exp", nil))},
+		{name: "unknown user", raw: rs256(with("sub", "ghost"))},
+		{name: "no user", raw: rs256(with("sub", nil))},
+		{name: "hs256", raw: hs256Raw},
+		{name: "unknown kid", raw: unknownKidRaw},
+		{name: "not a jwt", raw: "api-token-value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := authenticator.Authenticate(ctx, tt.raw)
+			if tt.wantRole == "" {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("got %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Role != tt.wantRole || p.UserID != tt.wantUser || p.Method != MethodJWT {
+				t.Errorf("got %+v, want role %s, user %s", p, tt.wantRole, tt.wantUser)
+			}
+			if p.TokenID != "" {
+				t.Errorf("JWT principal has token id %q", p.TokenID)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import "context"
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+var Roles = []string{RoleAdmin, RoleUser}
+
+// Способы аутентификации
+const (
+	MethodAPIToken = "api_token"
+	MethodJWT      = "jwt"
+)
+
+// Principal - аутентифицированный участник запроса
+type Principal struct {
+	UserID string
+	Role   string
+	// TokenID - идентификатор API-токена; у JWT пустой
+	TokenID string
+	Method  string
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p != nil && p.Role == RoleAdmin
+}
+
+// CanActAs сообщает, может ли участник действовать от имени userID
+func (p *Principal) CanActAs(userID string) bool {
+	return p.IsAdmin() || (p != nil && p.UserID != "" && p.UserID == userID)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает nil для неаутентифицированного запроса
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/internal/tracing"
+	"context"
+	"fmt"
+)
+
+// TeamScope решает, может ли участник управлять командой: администратор -
+// любой, руководитель команды - только своей. Для пользователя и PR команда
+// определяется по БД (для PR - команда автора)
+type TeamScope struct {
+	leads interfaces.TeamLeadRepository
+}
+
+func NewTeamScope(leads interfaces.TeamLeadRepository) *TeamScope {
+	return &TeamScope{leads: leads}
+}
+
+func (s *TeamScope) AuthorizeTeam(ctx context.Context, p *Principal, teamName string) error {
+	ctx, span := tracing.Start(ctx, "auth.TeamScope.AuthorizeTeam")
+	defer span.End()
+
+	return s.authorize(ctx, p, teamName)
+}
+
+func (s *TeamScope) AuthorizeUser(ctx context.Context, p *Principal, userID string) error {
+	ctx, span := tracing.Start(ctx, "auth.TeamScope.AuthorizeUser")
+	defer span.End()
+
+	if p.IsAdmin() {
+		return nil
+	}
+	teamName, found, err := s.leads.GetUserTeam(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrScopeNotFound
+	}
+	return s.authorize(ctx, p, teamName)
+}
+
+func (s *TeamScope) AuthorizePR(ctx context.Context, p *Principal, prID string) error {
+	ctx, span := tracing.Start(ctx, "auth.TeamScope.AuthorizePR")
+	defer span.End()
+
+	if p.IsAdmin() {
+		return nil
+	}
+	_, teamName, found, err := s.leads.GetPRAuthor(ctx, prID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrScopeNotFound
+	}
+	return s.authorize(ctx, p, teamName)
+}
+
+// AuthorizePRAuthor дополнительно пропускает автора PR
+func (s *TeamScope) AuthorizePRAuthor(ctx context.Context, p *Principal, prID string) error {
+	ctx, span := tracing.Start(ctx, "auth.TeamScope.AuthorizePRAuthor")
+	defer span.End()
+
+	if p.IsAdmin() {
+		return nil
+	}
+	authorID, teamName, found, err := s.leads.GetPRAuthor(ctx, prID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrScopeNotFound
+	}
+	if p != nil && p.UserID != "" && p.UserID == authorID {
+		return nil
+	}
+	return s.authorize(ctx, p, teamName)
+}
+
+func (s *TeamScope) authorize(ctx context.Context, p *Principal, teamName string) error {
+	if p.IsAdmin() {
+		return nil
+	}
+	if p == nil || p.UserID == "" || teamName == "" {
+		return ErrForbidden
+	}
+
+	isLead, err := s.leads.IsTeamLead(ctx, teamName, p.UserID)
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке прав руководителя команды: %v", err)
+	}
+	if !isLead {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/pkg/logger"
+	"AvitoTech/pkg/validator"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// tokenPrefix помогает узнать токен сервиса в логах и сканерах секретов
+	tokenPrefix      = "prs_"
+	tokenBytes       = 32
+	displayPrefixLen = 12
+	maxTokenTTLHours = 24 * 365
+)
+
+type Service struct {
+	tokens interfaces.TokenRepository
+	users  interfaces.UserRepository
+}
+
+func NewService(tokens interfaces.TokenRepository, users interfaces.UserRepository) *Service {
+	return &Service{tokens: tokens, users: users}
+}
+
+// HashToken - в БД хранится только SHA-256 от токена. Токены случайные и
+// длинные, поэтому медленный хеш паролей здесь не нужен
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Service) CreateToken(ctx context.Context, req dto.CreateTokenRequest) (*dto.CreateTokenResponse, error) {
+	if req.Name == "" || len(req.Name) > 255 {
+		return nil, fmt.Errorf("%w: name must be 1..255 characters", ErrInvalidToken)
+	}
+	if !slices.Contains(Roles, req.Role) {
+		return nil, fmt.Errorf("%w: role must be one of %v", ErrInvalidToken, Roles)
+	}
+	if req.ExpiresInHours < 0 || req.ExpiresInHours > maxTokenTTLHours {
+		return nil, fmt.Errorf("%w: expires_in_hours must be between 0 and %d", ErrInvalidToken, maxTokenTTLHours)
+	}
+	if req.Role == RoleUser {
+		if err := validator.ValidateUserID(req.UserID); err != nil {
+			return nil, fmt.Errorf("%w: user token requires user_id: %v", ErrInvalidToken, err)
+		}
+	}
+	if req.UserID != "" {
+		if _, err := s.users.GetUser(ctx, req.UserID); err != nil {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	raw, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+
+	token, err := s.tokens.CreateToken(ctx, dto.APITokenDTO{
+		TokenID: tokenID,
+		Name:    req.Name,
+		Role:    req.Role,
+		UserID:  req.UserID,
+		Prefix:  raw[:displayPrefixLen],
+	}, HashToken(raw), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, fmt.Errorf("token hash collision")
+	}
+
+	logger.FromContext(ctx).Info("Создан API-токен",
+		zap.String("token_id", token.TokenID),
+		zap.String("role", token.Role),
+		zap.String("user_id", token.UserID),
+	)
+
+	return &dto.CreateTokenResponse{Token: *token, Secret: raw}, nil
+}
+
+func (s *Service) ListTokens(ctx context.Context) ([]dto.APITokenDTO, error) {
+	return s.tokens.ListTokens(ctx)
+}
+
+func (s *Service) RevokeToken(ctx context.Context, tokenID string) error {
+	if tokenID == "" {
+		return fmt.Errorf("%w: token_id is required", ErrInvalidToken)
+	}
+
+	revoked, err := s.tokens.RevokeToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrTokenNotFound
+	}
+
+	logger.FromContext(ctx).Info("API-токен отозван", zap.String("token_id", tokenID))
+	return nil
+}
+
+// Authenticate проверяет bearer-токен и возвращает его владельца
+func (s *Service) Authenticate(ctx context.Context, raw string) (*Principal, error) {
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return nil, ErrUnauthorized
+	}
+
+	token, err := s.tokens.GetActiveTokenByHash(ctx, HashToken(raw))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrUnauthorized
+	}
+
+	if err := s.tokens.TouchToken(ctx, token.TokenID); err != nil {
+		// время последнего использования - справочная информация, запрос не валим
+		logger.FromContext(ctx).Warn("Не удалось обновить время использования токена",
+			zap.String("token_id", token.TokenID),
+			zap.Error(err),
+		)
+	}
+
+	return &Principal{UserID: token.UserID, Role: token.Role, TokenID: token.TokenID, Method: MethodAPIToken}, nil
+}
+
+// EnsureBootstrapToken регистрирует админский токен из конфигурации, чтобы
+// можно было выпустить остальные токены на чистой базе
+func (s *Service) EnsureBootstrapToken(ctx context.Context, raw string) error {
+	if !strings.HasPrefix(raw, tokenPrefix) {
+		return fmt.Errorf("bootstrap token must start with %q", tokenPrefix)
+	}
+
+	tokenID, err := randomHex(8)
+	if err != nil {
+		return err
+	}
+	token, err := s.tokens.CreateToken(ctx, dto.APITokenDTO{
+		TokenID: tokenID,
+		Name:    "bootstrap",
+		Role:    RoleAdmin,
+		Prefix:  raw[:min(displayPrefixLen, len(raw))],
+	}, HashToken(raw), nil)
+	if err != nil {
+		return err
+	}
+	if token != nil {
+		logger.FromContext(ctx).Info("Зарегистрирован bootstrap-токен администратора", zap.String("token_id", token.TokenID))
+	}
+	return nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,10 +1,51 @@
 package dto
 
 const (
+	StatusDraft  = "DRAFT"
 	StatusOpen   = "OPEN"
+	StatusClosed = "CLOSED"
 	StatusMerged = "MERGED"
 )
 
+const (
+	StrategyRandom     = "random"
+	StrategyRoundRobin = "round-robin"
+	StrategyWeighted   = "weighted"
+	StrategyLoadAware  = "load-aware"
+	// StrategyLeastLoaded - прежнее имя load-aware, оставлено для совместимости
+	// с конфигурациями и настройками команд
+	StrategyLeastLoaded = "least-loaded"
+)
+
+var Strategies = []string{
+	StrategyRandom,
+	StrategyRoundRobin,
+	StrategyLeastLoaded,
+	StrategyWeighted,
+	StrategyLoadAware,
+}
+
+const (
+	DefaultRequiredReviewers = 2
+	DefaultMinReviewers      = 0
+	DefaultRequiredApprovals = 1
+	DefaultMaxReviewers      = 5
+	MaxRequiredReviewers     = 10
+)
+
+// Вердикты ревьюверов
+const (
+	VerdictApproved         = "APPROVED"
+	VerdictChangesRequested = "CHANGES_REQUESTED"
+	VerdictCommented        = "COMMENTED"
+)
+
+var Verdicts = []string{
+	VerdictApproved,
+	VerdictChangesRequested,
+	VerdictCommented,
+}
+
 type TeamMemberDTO struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
@@ -20,6 +61,44 @@ type TeamResponse struct {
 	Team TeamDTO `json:"team"`
 }
 
+type TeamSettingsDTO struct {
+	TeamName               string `json:"team_name"`
+	RequiredReviewers      int    `json:"required_reviewers"`
+	MinReviewers           int    `json:"min_reviewers"`
+	RequiredApprovals      int    `json:"required_approvals"`
+	MaxReviewers           int    `json:"max_reviewers"`
+	Strategy               string `json:"strategy"`
+	AllowCrossTeamFallback bool   `json:"allow_cross_team_fallback"`
+}
+
+// UpdateTeamSettingsRequest - тело PUT /team/settings. Неуказанные поля
+// сохраняют текущие значения команды (или значения по умолчанию)
+type UpdateTeamSettingsRequest struct {
+	TeamName               string  `json:"team_name"`
+	RequiredReviewers      *int    `json:"required_reviewers,omitempty"`
+	MinReviewers           *int    `json:"min_reviewers,omitempty"`
+	RequiredApprovals      *int    `json:"required_approvals,omitempty"`
+	MaxReviewers           *int    `json:"max_reviewers,omitempty"`
+	Strategy               *string `json:"strategy,omitempty"`
+	AllowCrossTeamFallback *bool   `json:"allow_cross_team_fallback,omitempty"`
+	// AllowNoApprovals подтверждает отключение проверки одобрений перед
+	// мерджем (required_approvals = 0)
+	AllowNoApprovals bool `json:"allow_no_approvals,omitempty"`
+}
+
+type TeamSettingsResponse struct {
+	Settings TeamSettingsDTO `json:"settings"`
+}
+
+type TeamLeadsDTO struct {
+	TeamName string   `json:"team_name"`
+	LeadIDs  []string `json:"lead_ids"`
+}
+
+type TeamLeadsResponse struct {
+	Leads TeamLeadsDTO `json:"leads"`
+}
+
 type Error struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
@@ -34,12 +113,51 @@ type UserDTO struct {
 	Username string `json:"username"`
 	TeamName string `json:"team_name"`
 	IsActive bool   `json:"is_active"`
+	// MaxOpenReviews - личный лимит открытых ревью; nil - глобальный, 0 - без лимита
+	MaxOpenReviews *int `json:"max_open_reviews"`
 }
 
 type UserResponse struct {
 	User UserDTO `json:"user"`
 }
 
+type ReviewHandoffDTO struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldUserID     string `json:"old_user_id"`
+	ReplacedBy    string `json:"replaced_by,omitempty"`
+}
+
+type HandoffReportDTO struct {
+	Reassigned  []ReviewHandoffDTO `json:"reassigned"`
+	NoCandidate []ReviewHandoffDTO `json:"no_candidate"`
+}
+
+type OpenReviewDTO struct {
+	PullRequestID string
+	AuthorID      string
+	AuthorTeam    string
+	ReviewerID    string
+	Reviewers     []string
+	// Declined - отказавшиеся от ревью этого PR
+	Declined []string
+}
+
+type DeactivateTeamRequest struct {
+	TeamName string   `json:"team_name"`
+	UserIDs  []string `json:"user_ids,omitempty"`
+}
+
+type DeactivateTeamResponse struct {
+	TeamName     string           `json:"team_name"`
+	Deactivated  []string         `json:"deactivated"`
+	Reassignment HandoffReportDTO `json:"reassignment"`
+}
+
+type SetUserActiveResponse struct {
+	User         UserDTO           `json:"user"`
+	Reassignment *HandoffReportDTO `json:"reassignment,omitempty"`
+}
+
 type PullRequestShortDTO struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
@@ -52,6 +170,12 @@ type SetUserActiveRequest struct {
 	IsActive bool   `json:"is_active"`
 }
 
+// SetMaxOpenReviewsRequest - null в max_open_reviews возвращает глобальный лимит
+type SetMaxOpenReviewsRequest struct {
+	UserID         string `json:"user_id"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+}
+
 type GetUserReviewsResponse struct {
 	UserID       string                `json:"user_id"`
 	PullRequests []PullRequestShortDTO `json:"pull_requests"`
@@ -63,12 +187,16 @@ type PullRequestDTO struct {
 	AuthorID          string   `json:"author_id"`
 	Status            string   `json:"status"`
 	AssignedReviewers []string `json:"assigned_reviewers"`
+	// Understaffed - ревьюверов меньше required_reviewers команды
+	Understaffed bool `json:"understaffed,omitempty"`
 }
 
 type CreatePullRequestRequest struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	// Draft создаёт черновик: ревьюверы назначаются при переводе в OPEN
+	Draft bool `json:"draft,omitempty"`
 }
 
 type PullRequestResponse struct {
@@ -77,6 +205,13 @@ type PullRequestResponse struct {
 
 type MergePullRequestRequest struct {
 	PullRequestID string `json:"pull_request_id"`
+	// OverrideApprovals - мердж без нужных одобрений, только для администратора
+	OverrideApprovals bool `json:"override_approvals,omitempty"`
+}
+
+// PullRequestActionRequest - тело /pullRequest/close, /reopen и /ready
+type PullRequestActionRequest struct {
+	PullRequestID string `json:"pull_request_id"`
 }
 
 type MergePullRequestResponse struct {
@@ -92,12 +227,126 @@ type MergedPullRequestDTO struct {
 	MergedAt          string   `json:"mergedAt"`
 }
 
+type ReviewPullRequestRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Verdict       string `json:"verdict"`
+}
+
+// ReviewDTO - вердикт ревьювера; пустой Verdict - ревью ещё не отправлено
+type ReviewDTO struct {
+	ReviewerID string `json:"reviewer_id"`
+	Verdict    string `json:"verdict,omitempty"`
+	ReviewedAt string `json:"reviewed_at,omitempty"`
+}
+
+type ReviewPullRequestResponse struct {
+	PR      PullRequestDTO `json:"pr"`
+	Reviews []ReviewDTO    `json:"reviews"`
+}
+
+// ReviewerChangeRequest - тело /pullRequest/reviewers/add и /remove
+type ReviewerChangeRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+}
+
+type DeclineReviewRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Reason        string `json:"reason"`
+}
+
 type ReassignPullRequestRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 	OldUserID     string `json:"old_user_id"`
 }
 
+// ReassignPullRequestResponse - ответ reassign и decline; ReplacedBy пуст,
+// если ревьювер отказался, а замены не нашлось
 type ReassignPullRequestResponse struct {
 	PR         PullRequestDTO `json:"pr"`
-	ReplacedBy string         `json:"replaced_by"`
+	ReplacedBy string         `json:"replaced_by,omitempty"`
+}
+
+type PoolStatsDTO struct {
+	MaxConns                int32 `json:"max_conns"`
+	TotalConns              int32 `json:"total_conns"`
+	AcquiredConns           int32 `json:"acquired_conns"`
+	IdleConns               int32 `json:"idle_conns"`
+	ConstructingConns       int32 `json:"constructing_conns"`
+	AcquireCount            int64 `json:"acquire_count"`
+	AcquireDurationMs       int64 `json:"acquire_duration_ms"`
+	EmptyAcquireCount       int64 `json:"empty_acquire_count"`
+	CanceledAcquireCount    int64 `json:"canceled_acquire_count"`
+	NewConnsCount           int64 `json:"new_conns_count"`
+	MaxLifetimeDestroyCount int64 `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64 `json:"max_idle_destroy_count"`
+}
+
+type APITokenDTO struct {
+	TokenID    string `json:"token_id"`
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	UserID     string `json:"user_id,omitempty"`
+	Prefix     string `json:"prefix"`
+	CreatedAt  string `json:"created_at"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	RevokedAt  string `json:"revoked_at,omitempty"`
+}
+
+type CreateTokenRequest struct {
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	UserID         string `json:"user_id,omitempty"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty"`
+}
+
+// CreateTokenResponse - секрет токена показывается только один раз, в БД
+// хранится лишь его хеш
+type CreateTokenResponse struct {
+	Token  APITokenDTO `json:"token"`
+	Secret string      `json:"secret"`
+}
+
+type TokensResponse struct {
+	Tokens []APITokenDTO `json:"tokens"`
+}
+
+type RevokeTokenRequest struct {
+	TokenID string `json:"token_id"`
+}
+
+type ConfigResponse struct {
+	Config map[string]any `json:"config"`
+}
+
+type PoolStatsResponse struct {
+	Pool PoolStatsDTO `json:"pool"`
+}
+
+type DependencyStatusDTO struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Info      string `json:"info,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string                         `json:"status"`
+	Checks map[string]DependencyStatusDTO `json:"checks,omitempty"`
+}
+
+// IdempotencyRecordDTO - запрос с Idempotency-Key и, после выполнения,
+// сохранённый ответ (StatusCode == 0, пока запрос выполняется)
+type IdempotencyRecordDTO struct {
+	Scope       string
+	Key         string
+	Method      string
+	Path        string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
 }
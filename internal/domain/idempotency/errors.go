@@ -0,0 +1,16 @@
+package idempotency
+
+import "errors"
+
+const (
+	KeyMismatch   = "IDEMPOTENCY_KEY_MISMATCH"
+	KeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	BadRequest    = "BAD_REQUEST"
+	InternalError = "INTERNAL_ERROR"
+)
+
+var (
+	ErrKeyMismatch   = errors.New("idempotency key was used with a different request")
+	ErrKeyInProgress = errors.New("request with this idempotency key is still in progress")
+	ErrInvalidKey    = errors.New("invalid idempotency key")
+)
@@ -0,0 +1,152 @@
+package idempotency
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/internal/tracing"
+	"AvitoTech/pkg/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	maxKeyLength    = 255
+	cleanupInterval = 10 * time.Minute
+	cleanupTimeout  = 30 * time.Second
+)
+
+type Service struct {
+	repo        interfaces.IdempotencyRepository
+	ttl         time.Duration
+	lockTimeout time.Duration
+
+	stopCleanup context.CancelFunc
+	cleanupDone sync.WaitGroup
+}
+
+// NewService: ttl - сколько хранится ответ, lockTimeout - через сколько
+// незавершённый запрос (например, после падения экземпляра) перестаёт
+// блокировать ключ
+func NewService(repo interfaces.IdempotencyRepository, ttl, lockTimeout time.Duration) *Service {
+	return &Service{repo: repo, ttl: ttl, lockTimeout: lockTimeout}
+}
+
+func ValidateKey(key string) error {
+	if key == "" || len(key) > maxKeyLength {
+		return fmt.Errorf("%w: must be 1..%d characters", ErrInvalidKey, maxKeyLength)
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return fmt.Errorf("%w: only printable ASCII without spaces is allowed", ErrInvalidKey)
+		}
+	}
+	return nil
+}
+
+// RequestHash - отпечаток запроса, с которым сверяются повторы
+func RequestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin закрепляет ключ за запросом. Возвращает nil, если запрос нужно
+// выполнить (после этого обязателен Complete или Release), или сохранённый
+// ответ для повтора
+func (s *Service) Begin(ctx context.Context, record dto.IdempotencyRecordDTO) (*dto.IdempotencyRecordDTO, error) {
+	ctx, span := tracing.Start(ctx, "idempotency.Service.Begin")
+	defer span.End()
+
+	// вторая попытка нужна, если запись истекла между Reserve и Get
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.repo.Reserve(ctx, record, s.ttl, s.lockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		stored, err := s.repo.Get(ctx, record.Scope, record.Key)
+		if err != nil {
+			return nil, err
+		}
+		if stored == nil {
+			continue
+		}
+		if stored.RequestHash != record.RequestHash {
+			return nil, ErrKeyMismatch
+		}
+		if stored.StatusCode == 0 {
+			return nil, ErrKeyInProgress
+		}
+		return stored, nil
+	}
+	return nil, ErrKeyInProgress
+}
+
+func (s *Service) Complete(ctx context.Context, record dto.IdempotencyRecordDTO) error {
+	ctx, span := tracing.Start(ctx, "idempotency.Service.Complete")
+	defer span.End()
+
+	return s.repo.SaveResponse(ctx, record)
+}
+
+// Release освобождает ключ без сохранения ответа, чтобы запрос можно было
+// повторить (например, после ошибки 5xx)
+func (s *Service) Release(ctx context.Context, scope, key string) error {
+	ctx, span := tracing.Start(ctx, "idempotency.Service.Release")
+	defer span.End()
+
+	return s.repo.Delete(ctx, scope, key)
+}
+
+// StartCleanup периодически удаляет истёкшие ключи
+func (s *Service) StartCleanup() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopCleanup = cancel
+
+	s.cleanupDone.Add(1)
+	go func() {
+		defer s.cleanupDone.Done()
+
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			deleteCtx, cancel := context.WithTimeout(ctx, cleanupTimeout)
+			deleted, err := s.repo.DeleteExpired(deleteCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				logger.Log.Warn("Не удалось удалить истёкшие ключи идемпотентности", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				logger.Log.Debug("Удалены истёкшие ключи идемпотентности", zap.Int64("deleted", deleted))
+			}
+		}
+	}()
+}
+
+func (s *Service) StopCleanup() {
+	if s.stopCleanup != nil {
+		s.stopCleanup()
+		s.cleanupDone.Wait()
+	}
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Reserve закрепляет ключ за запросом. Занятый ключ можно перехватить,
+	// только если запись истекла или запрос завис дольше lockTimeout
+	Reserve(ctx context.Context, record dto.IdempotencyRecordDTO, ttl, lockTimeout time.Duration) (bool, error)
+	// Get возвращает nil без ошибки, если ключа нет или он истёк
+	Get(ctx context.Context, scope, key string) (*dto.IdempotencyRecordDTO, error)
+	SaveResponse(ctx context.Context, record dto.IdempotencyRecordDTO) error
+	Delete(ctx context.Context, scope, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
@@ -8,17 +8,33 @@ import (
 type PRRepository interface {
 	PRExists(ctx context.Context, prID string) (bool, error)
 
-	CreatePR(ctx context.Context, prID, prName, authorID string) error
+	CreatePR(ctx context.Context, prID, prName, authorID, status string) error
 
 	GetPR(ctx context.Context, prID string) (*dto.PullRequestDTO, error)
 
-	UpdatePRStatus(ctx context.Context, prID, status string) error
+	// TransitionStatus переводит PR из from в to; false, если PR уже не в from
+	TransitionStatus(ctx context.Context, prID, from, to string) (bool, error)
 
-	SetMergedAt(ctx context.Context, prID string) error
+	// SetUnderstaffed отмечает PR, которому не хватило ревьюверов
+	SetUnderstaffed(ctx context.Context, prID string, understaffed bool) error
 
 	GetReviewers(ctx context.Context, prID string) ([]string, error)
 	AssignReviewers(ctx context.Context, prID string, reviewerIDs []string) error
-	RemoveReviewer(ctx context.Context, prID, reviewerID string) error
+	// RemoveReviewer возвращает false, если ревьювер не назначен на PR
+	RemoveReviewer(ctx context.Context, prID, reviewerID string) (bool, error)
 	AddReviewer(ctx context.Context, prID, reviewerID string) error
 	IsReviewerAssigned(ctx context.Context, prID, reviewerID string) (bool, error)
+
+	// SetReviewVerdict возвращает false, если ревьювер не назначен на PR
+	SetReviewVerdict(ctx context.Context, prID, reviewerID, verdict string) (bool, error)
+	GetReviews(ctx context.Context, prID string) ([]dto.ReviewDTO, error)
+	// ResetReviewVerdicts возвращает ревью всех ревьюверов PR в ожидание
+	ResetReviewVerdicts(ctx context.Context, prID string) error
+
+	// RecordDecline запоминает отказ: отказавшийся больше не назначается на этот PR
+	RecordDecline(ctx context.Context, prID, reviewerID, reason string) error
+	GetDeclinedReviewers(ctx context.Context, prID string) ([]string, error)
+
+	GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error)
+	GetOpenReviewsByReviewers(ctx context.Context, reviewerIDs []string) ([]dto.OpenReviewDTO, error)
 }
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+)
+
+type ReviewHandoffPlanner interface {
+	PlanReviewHandoff(ctx context.Context, teamName string, userIDs []string) (*dto.HandoffReportDTO, error)
+	// UpdateHandoffStaffing пересчитывает отметку understaffed на PR из отчёта
+	// о применённой передаче ревью
+	UpdateHandoffStaffing(ctx context.Context, teamName string, report *dto.HandoffReportDTO) error
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import "context"
+
+type TeamLeadRepository interface {
+	GetTeamLeads(ctx context.Context, teamName string) ([]string, error)
+	ReplaceTeamLeads(ctx context.Context, teamName string, userIDs []string) error
+	// IsTeamLead учитывает только руководителей, которые всё ещё состоят в команде
+	IsTeamLead(ctx context.Context, teamName, userID string) (bool, error)
+
+	// GetUserTeam и GetPRAuthor возвращают found=false, если пользователя или
+	// PR нет. Команда PR - команда его автора, пустая строка - вне команды
+	GetUserTeam(ctx context.Context, userID string) (teamName string, found bool, err error)
+	GetPRAuthor(ctx context.Context, prID string) (authorID, teamName string, found bool, err error)
+}
@@ -9,4 +9,10 @@ type TeamRepository interface {
 	CreateTeam(ctx context.Context, team dto.TeamDTO) error
 	TeamExists(ctx context.Context, name string) (bool, error)
 	GetTeam(ctx context.Context, name string) (dto.TeamDTO, error)
+
+	GetTeamSettings(ctx context.Context, name string) (*dto.TeamSettingsDTO, error)
+	UpsertTeamSettings(ctx context.Context, settings dto.TeamSettingsDTO) error
+
+	LockTeam(ctx context.Context, name string) error
+	TryLockTeam(ctx context.Context, name string) (bool, error)
 }
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"time"
+)
+
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token dto.APITokenDTO, hash string, expiresAt *time.Time) (*dto.APITokenDTO, error)
+	// GetActiveTokenByHash возвращает nil без ошибки, если токена нет,
+	// он отозван или истёк
+	GetActiveTokenByHash(ctx context.Context, hash string) (*dto.APITokenDTO, error)
+	ListTokens(ctx context.Context) ([]dto.APITokenDTO, error)
+	RevokeToken(ctx context.Context, tokenID string) (bool, error)
+	TouchToken(ctx context.Context, tokenID string) error
+}
@@ -8,7 +8,12 @@ import (
 type UserRepository interface {
 	GetUserReviews(ctx context.Context, userID string) ([]dto.PullRequestShortDTO, error)
 	SetUserActive(ctx context.Context, userID string, isActive bool) (*dto.UserDTO, error)
+	DeactivateUsers(ctx context.Context, userIDs []string, plan dto.HandoffReportDTO) (*dto.HandoffReportDTO, error)
 	GetUser(ctx context.Context, userID string) (*dto.UserDTO, error)
 	CreateOrUpdateUser(ctx context.Context, member dto.TeamMemberDTO, teamName string) error
 	GetTeamByName(ctx context.Context, teamName string) (*dto.TeamDTO, error)
+	GetActiveUsersOutsideTeam(ctx context.Context, teamName string) ([]dto.UserDTO, error)
+
+	SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (*dto.UserDTO, error)
+	GetReviewCapacities(ctx context.Context, userIDs []string) (map[string]int, error)
 }
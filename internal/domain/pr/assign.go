@@ -1,53 +1,106 @@
 package pr
 
 import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/tracing"
 	"AvitoTech/pkg/logger"
 	"context"
 	"math/rand"
+	"slices"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-func (s *Service) assignReviewers(ctx context.Context, authorID, teamName string) ([]string, error) {
-	logger.Log.Info("Автоназначение ревьюверов",
+// assignReviewers выбирает ревьюверов нового PR; exclude - кроме автора
+// не назначаемые на этот PR (отказавшиеся от ревью)
+func (s *Service) assignReviewers(ctx context.Context, authorID string, settings dto.TeamSettingsDTO, exclude []string) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.assignReviewers")
+	defer func() { tracing.End(span, err) }()
+
+	teamName := settings.TeamName
+	logger.FromContext(ctx).Info("Автоназначение ревьюверов",
 		zap.String("author_id", authorID),
 		zap.String("team_name", teamName),
+		zap.Int("required_reviewers", settings.RequiredReviewers),
 	)
 
 	team, err := s.userRepo.GetTeamByName(ctx, teamName)
 	if err != nil {
-		logger.Log.Error("Ошибка при получении команды для автоназначения",
+		logger.FromContext(ctx).Error("Ошибка при получении команды для автоназначения",
 			zap.String("team_name", teamName),
 			zap.Error(err),
 		)
 		return nil, err
 	}
 
+	excluded := append([]string{authorID}, exclude...)
+
 	var candidates []string
 	for _, member := range team.Members {
-		if member.IsActive && member.UserID != authorID {
+		if member.IsActive && !slices.Contains(excluded, member.UserID) {
 			candidates = append(candidates, member.UserID)
 		}
 	}
 
-	logger.Log.Info("Найдены кандидаты для ревью",
+	candidates, saturated, err := s.withinCapacity(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("Найдены кандидаты для ревью",
 		zap.Int("candidates_count", len(candidates)),
 		zap.Strings("candidates", candidates),
 	)
 
-	if len(candidates) == 0 {
-		logger.Log.Warn("Нет доступных кандидатов для ревью", zap.String("team_name", teamName))
-		return []string{}, nil
+	reviewers, err := s.pickReviewers(ctx, settings, candidates, settings.RequiredReviewers)
+	if err != nil {
+		logger.FromContext(ctx).Error("Ошибка при выборе ревьюверов",
+			zap.String("team_name", teamName),
+			zap.Error(err),
+		)
+		return nil, err
 	}
 
-	if len(candidates) == 1 {
-		logger.Log.Info("Назначен 1 ревьювер", zap.String("reviewer", candidates[0]))
-		return candidates, nil
+	if missing := settings.RequiredReviewers - len(reviewers); missing > 0 && settings.AllowCrossTeamFallback {
+		extra, outsidersSaturated, err := s.pickFromOtherTeams(ctx, settings, missing, append(excluded, reviewers...))
+		if err != nil {
+			return nil, err
+		}
+		saturated += outsidersSaturated
+		if len(extra) > 0 {
+			logger.FromContext(ctx).Info("Добавлены ревьюверы из других команд",
+				zap.String("team_name", teamName),
+				zap.Strings("reviewers", extra),
+			)
+		}
+		reviewers = append(reviewers, extra...)
+	}
+
+	// если кандидаты есть, но все заняты, PR создаётся с нехваткой ревьюверов,
+	// а не перегружает кого-то сверх лимита
+	if len(reviewers) < settings.MinReviewers && saturated > 0 {
+		logger.FromContext(ctx).Warn("Кандидаты исчерпали лимит ревью, ревьюверов меньше min_reviewers",
+			zap.String("team_name", teamName),
+			zap.Int("min_reviewers", settings.MinReviewers),
+			zap.Int("found", len(reviewers)),
+			zap.Int("saturated", saturated),
+		)
+	} else if len(reviewers) < settings.MinReviewers {
+		logger.FromContext(ctx).Warn("Недостаточно кандидатов для ревью",
+			zap.String("team_name", teamName),
+			zap.Int("min_reviewers", settings.MinReviewers),
+			zap.Int("found", len(reviewers)),
+		)
+		return nil, ErrNotEnoughReviewers
+	}
+
+	if len(reviewers) == 0 {
+		logger.FromContext(ctx).Warn("Нет доступных кандидатов для ревью", zap.String("team_name", teamName))
+		return []string{}, nil
 	}
 
-	reviewers := selectRandomReviewers(candidates, 2)
-	logger.Log.Info("Назначены ревьюверы",
+	logger.FromContext(ctx).Info("Назначены ревьюверы",
 		zap.Int("count", len(reviewers)),
 		zap.Strings("reviewers", reviewers),
 	)
@@ -55,6 +108,72 @@ func (s *Service) assignReviewers(ctx context.Context, authorID, teamName string
 	return reviewers, nil
 }
 
+// pickFromOtherTeams выбирает ревьюверов из других команд; вторым значением
+// возвращает число кандидатов, отсеянных по лимиту открытых ревью
+func (s *Service) pickFromOtherTeams(ctx context.Context, settings dto.TeamSettingsDTO, count int, exclude []string) ([]string, int, error) {
+	outsiders, err := s.lockedOutsiders(ctx, settings.TeamName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	excludeMap := make(map[string]bool, len(exclude))
+	for _, userID := range exclude {
+		excludeMap[userID] = true
+	}
+
+	var candidates []string
+	for _, userID := range outsiders {
+		if !excludeMap[userID] {
+			candidates = append(candidates, userID)
+		}
+	}
+
+	candidates, saturated, err := s.withinCapacity(ctx, candidates)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	selected, err := s.pickReviewers(ctx, settings, candidates, count)
+	return selected, saturated, err
+}
+
+// lockedOutsiders возвращает активных пользователей других команд, чьи
+// команды удалось заблокировать, чтобы их нагрузка не менялась параллельно.
+// Команда запроса уже заблокирована, поэтому чужие блокируются без ожидания:
+// ожидание в обратном порядке двумя транзакциями привело бы к взаимоблокировке.
+// Занятые команды пропускаются
+func (s *Service) lockedOutsiders(ctx context.Context, teamName string) ([]string, error) {
+	users, err := s.userRepo.GetActiveUsersOutsideTeam(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := make(map[string]bool)
+	var busy []string
+	outsiders := make([]string, 0, len(users))
+	for _, user := range users {
+		ok, checked := locked[user.TeamName]
+		if !checked {
+			ok, err = s.teamRepo.TryLockTeam(ctx, user.TeamName)
+			if err != nil {
+				return nil, err
+			}
+			locked[user.TeamName] = ok
+			if !ok {
+				busy = append(busy, user.TeamName)
+			}
+		}
+		if ok {
+			outsiders = append(outsiders, user.UserID)
+		}
+	}
+
+	if len(busy) > 0 {
+		logger.FromContext(ctx).Info("Команды заняты другими назначениями и пропущены", zap.Strings("teams", busy))
+	}
+	return outsiders, nil
+}
+
 func selectRandomReviewers(candidates []string, maxCount int) []string {
 	if len(candidates) <= maxCount {
 		return candidates
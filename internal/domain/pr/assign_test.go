@@ -0,0 +1,25 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestLockedOutsidersSkipsBusyTeams(t *testing.T) {
+	repo := newMemRepo()
+	repo.addTeam(backendSettings(), activeUser("u1"))
+	repo.addTeam(dto.TeamSettingsDTO{TeamName: "frontend"}, activeUser("f1"), activeUser("f2"), dto.UserDTO{UserID: "f3"})
+	repo.addTeam(dto.TeamSettingsDTO{TeamName: "mobile"}, activeUser("m1"))
+	repo.busyTeams["mobile"] = true
+
+	svc, _ := newTestService(t, repo, 0)
+	got, err := svc.lockedOutsiders(context.Background(), "backend")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"f1", "f2"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
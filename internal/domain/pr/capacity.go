@@ -0,0 +1,95 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/pkg/logger"
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// reviewLimits возвращает лимиты открытых ревью кандидатов: личный из users,
+// иначе глобальный. 0 - без лимита
+func (s *Service) reviewLimits(ctx context.Context, userIDs []string) (map[string]int, error) {
+	overrides, err := s.userRepo.GetReviewCapacities(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении лимитов ревью: %w", err)
+	}
+
+	limits := make(map[string]int, len(userIDs))
+	for _, userID := range userIDs {
+		limit, ok := overrides[userID]
+		if !ok {
+			limit = s.maxOpenReviews
+		}
+		limits[userID] = limit
+	}
+	return limits, nil
+}
+
+// withinCapacity убирает кандидатов, уже ревьюящих столько открытых PR,
+// сколько позволяет их лимит. Возвращает оставшихся и число отсеянных
+func (s *Service) withinCapacity(ctx context.Context, candidates []string) ([]string, int, error) {
+	if len(candidates) == 0 {
+		return candidates, 0, nil
+	}
+
+	limits, err := s.reviewLimits(ctx, candidates)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var limited []string
+	for _, userID := range candidates {
+		if limits[userID] > 0 {
+			limited = append(limited, userID)
+		}
+	}
+	if len(limited) == 0 {
+		return candidates, 0, nil
+	}
+
+	loads, err := s.prRepo.GetOpenReviewCounts(ctx, limited)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении нагрузки ревьюверов: %w", err)
+	}
+
+	available := make([]string, 0, len(candidates))
+	var saturated []string
+	for _, userID := range candidates {
+		if limit := limits[userID]; limit > 0 && loads[userID] >= limit {
+			saturated = append(saturated, userID)
+			continue
+		}
+		available = append(available, userID)
+	}
+
+	if len(saturated) > 0 {
+		logger.FromContext(ctx).Info("Кандидаты исчерпали лимит открытых ревью", zap.Strings("users", saturated))
+	}
+	return available, len(saturated), nil
+}
+
+// updateStaffing помечает PR как understaffed, если ревьюверов меньше
+// required_reviewers команды, и снимает отметку, когда их хватает
+func (s *Service) updateStaffing(ctx context.Context, pr *dto.PullRequestDTO, settings dto.TeamSettingsDTO) error {
+	understaffed := len(pr.AssignedReviewers) < settings.RequiredReviewers
+	if understaffed == pr.Understaffed {
+		return nil
+	}
+
+	if err := s.prRepo.SetUnderstaffed(ctx, pr.PullRequestID, understaffed); err != nil {
+		return fmt.Errorf("ошибка при обновлении отметки understaffed: %w", err)
+	}
+	pr.Understaffed = understaffed
+
+	if understaffed {
+		logger.FromContext(ctx).Warn("PR назначено меньше ревьюверов, чем требуется",
+			zap.String("pr_id", pr.PullRequestID),
+			zap.Int("required_reviewers", settings.RequiredReviewers),
+			zap.Int("assigned", len(pr.AssignedReviewers)),
+		)
+	}
+	return nil
+}
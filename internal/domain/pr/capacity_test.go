@@ -0,0 +1,70 @@
+package pr
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestWithinCapacity(t *testing.T) {
+	tests := []struct {
+		name          string
+		globalLimit   int
+		capacities    map[string]int
+		loads         map[string]int
+		want          []string
+		wantSaturated int
+	}{
+		{
+			name:  "no limits",
+			loads: map[string]int{"u1": 10, "u2": 3},
+			want:  []string{"u1", "u2", "u3"},
+		},
+		{
+			name:          "global limit",
+			globalLimit:   2,
+			loads:         map[string]int{"u1": 2, "u2": 1, "u3": 3},
+			want:          []string{"u2"},
+			wantSaturated: 2,
+		},
+		{
+			name:          "personal limit overrides global",
+			globalLimit:   2,
+			capacities:    map[string]int{"u1": 5, "u2": 1},
+			loads:         map[string]int{"u1": 2, "u2": 1},
+			want:          []string{"u1", "u3"},
+			wantSaturated: 1,
+		},
+		{
+			name:        "personal zero removes the limit",
+			globalLimit: 1,
+			capacities:  map[string]int{"u1": 0},
+			loads:       map[string]int{"u1": 7},
+			want:        []string{"u1", "u2", "u3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo()
+			for userID, limit := range tt.capacities {
+				repo.capacities[userID] = limit
+			}
+			for userID, load := range tt.loads {
+				repo.loads[userID] = load
+			}
+
+			svc, _ := newTestService(t, repo, tt.globalLimit)
+			got, saturated, err := svc.withinCapacity(context.Background(), []string{"u1", "u2", "u3"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("available %v, want %v", got, tt.want)
+			}
+			if saturated != tt.wantSaturated {
+				t.Errorf("saturated %d, want %d", saturated, tt.wantSaturated)
+			}
+		})
+	}
+}
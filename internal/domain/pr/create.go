@@ -2,6 +2,8 @@ package pr
 
 import (
 	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
 	"AvitoTech/pkg/logger"
 	"AvitoTech/pkg/validator"
 	"context"
@@ -10,7 +12,10 @@ import (
 	"go.uber.org/zap"
 )
 
-func (s *Service) CreatePR(ctx context.Context, req dto.CreatePullRequestRequest) (*dto.PullRequestDTO, error) {
+func (s *Service) CreatePR(ctx context.Context, req dto.CreatePullRequestRequest) (_ *dto.PullRequestDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.CreatePR")
+	defer func() { tracing.End(span, err) }()
+
 	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
 		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
 	}
@@ -21,7 +26,7 @@ func (s *Service) CreatePR(ctx context.Context, req dto.CreatePullRequestRequest
 		return nil, fmt.Errorf("invalid author_id: %w", err)
 	}
 
-	logger.Log.Info("Создание PR",
+	logger.FromContext(ctx).Info("Создание PR",
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("pr_name", req.PullRequestName),
 		zap.String("author_id", req.AuthorID),
@@ -32,41 +37,74 @@ func (s *Service) CreatePR(ctx context.Context, req dto.CreatePullRequestRequest
 		return nil, fmt.Errorf("ошибка при проверке существования PR: %w", err)
 	}
 	if exists {
-		logger.Log.Warn("PR уже существует", zap.String("pr_id", req.PullRequestID))
+		logger.FromContext(ctx).Warn("PR уже существует", zap.String("pr_id", req.PullRequestID))
 		return nil, ErrPRExists
 	}
 
-	author, err := s.userRepo.GetUser(ctx, req.AuthorID)
+	settings, err := s.authorSettings(ctx, req.AuthorID)
 	if err != nil {
-		logger.Log.Error("Автор не найден", zap.String("author_id", req.AuthorID), zap.Error(err))
-		return nil, ErrAuthorNotFound
+		return nil, err
 	}
 
-	if author.TeamName == "" {
-		logger.Log.Warn("У автора нет команды", zap.String("author_id", req.AuthorID))
-		return nil, fmt.Errorf("author has no team")
+	status := dto.StatusOpen
+	if req.Draft {
+		status = dto.StatusDraft
 	}
 
-	reviewers, err := s.assignReviewers(ctx, req.AuthorID, author.TeamName)
-	if err != nil {
-		logger.Log.Error("Ошибка при автоназначении ревьюверов", zap.Error(err))
-		return nil, fmt.Errorf("ошибка при назначении ревьюверов: %w", err)
-	}
+	reviewers := []string{}
+	understaffed := false
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if req.Draft {
+			// черновику ревьюверы назначаются при переводе в OPEN
+			if err := s.prRepo.CreatePR(ctx, req.PullRequestID, req.PullRequestName, req.AuthorID, status); err != nil {
+				logger.FromContext(ctx).Error("Ошибка при создании PR в БД", zap.Error(err))
+				return fmt.Errorf("ошибка при создании PR: %w", err)
+			}
+			return nil
+		}
 
-	if err := s.prRepo.CreatePR(ctx, req.PullRequestID, req.PullRequestName, req.AuthorID); err != nil {
-		logger.Log.Error("Ошибка при создании PR в БД", zap.Error(err))
-		return nil, fmt.Errorf("ошибка при создании PR: %w", err)
-	}
+		if err := s.teamRepo.LockTeam(ctx, settings.TeamName); err != nil {
+			return err
+		}
 
-	if len(reviewers) > 0 {
-		if err := s.prRepo.AssignReviewers(ctx, req.PullRequestID, reviewers); err != nil {
-			logger.Log.Error("Ошибка при назначении ревьюверов в БД", zap.Error(err))
-			return nil, fmt.Errorf("ошибка при назначении ревьюверов: %w", err)
+		var err error
+		reviewers, err = s.assignReviewers(ctx, req.AuthorID, *settings, nil)
+		if err != nil {
+			logger.FromContext(ctx).Error("Ошибка при автоназначении ревьюверов", zap.Error(err))
+			return fmt.Errorf("ошибка при назначении ревьюверов: %w", err)
 		}
+
+		if err := s.prRepo.CreatePR(ctx, req.PullRequestID, req.PullRequestName, req.AuthorID, status); err != nil {
+			logger.FromContext(ctx).Error("Ошибка при создании PR в БД", zap.Error(err))
+			return fmt.Errorf("ошибка при создании PR: %w", err)
+		}
+
+		if len(reviewers) > 0 {
+			if err := s.prRepo.AssignReviewers(ctx, req.PullRequestID, reviewers); err != nil {
+				logger.FromContext(ctx).Error("Ошибка при назначении ревьюверов в БД", zap.Error(err))
+				return fmt.Errorf("ошибка при назначении ревьюверов: %w", err)
+			}
+		}
+
+		created := &dto.PullRequestDTO{PullRequestID: req.PullRequestID, AssignedReviewers: reviewers}
+		if err := s.updateStaffing(ctx, created, *settings); err != nil {
+			return err
+		}
+		understaffed = created.Understaffed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.PRCreated()
+	if !req.Draft {
+		s.observeAssigned(len(reviewers), understaffed, *settings)
 	}
 
-	logger.Log.Info("PR успешно создан",
+	logger.FromContext(ctx).Info("PR успешно создан",
 		zap.String("pr_id", req.PullRequestID),
+		zap.String("status", status),
 		zap.Int("reviewers_count", len(reviewers)),
 		zap.Strings("reviewers", reviewers),
 	)
@@ -75,7 +113,40 @@ func (s *Service) CreatePR(ctx context.Context, req dto.CreatePullRequestRequest
 		PullRequestID:     req.PullRequestID,
 		PullRequestName:   req.PullRequestName,
 		AuthorID:          req.AuthorID,
-		Status:            dto.StatusOpen,
+		Status:            status,
 		AssignedReviewers: reviewers,
+		Understaffed:      understaffed,
 	}, nil
 }
+
+// authorSettings возвращает настройки команды автора PR
+func (s *Service) authorSettings(ctx context.Context, authorID string) (*dto.TeamSettingsDTO, error) {
+	author, err := s.userRepo.GetUser(ctx, authorID)
+	if err != nil {
+		logger.FromContext(ctx).Error("Автор не найден", zap.String("author_id", authorID), zap.Error(err))
+		return nil, ErrAuthorNotFound
+	}
+
+	if author.TeamName == "" {
+		logger.FromContext(ctx).Warn("У автора нет команды", zap.String("author_id", authorID))
+		return nil, fmt.Errorf("author has no team")
+	}
+
+	settings, err := s.teamRepo.GetTeamSettings(ctx, author.TeamName)
+	if err != nil {
+		logger.FromContext(ctx).Error("Ошибка при получении настроек команды", zap.String("team_name", author.TeamName), zap.Error(err))
+		return nil, fmt.Errorf("ошибка при получении настроек команды: %w", err)
+	}
+	return settings, nil
+}
+
+// observeAssigned учитывает в метриках ревьюверов, назначенных при открытии PR
+func (s *Service) observeAssigned(reviewers int, understaffed bool, settings dto.TeamSettingsDTO) {
+	metrics.ReviewersAssigned(reviewers)
+	if understaffed {
+		metrics.PRUnderstaffed()
+	}
+	if reviewers == 0 && settings.RequiredReviewers > 0 {
+		metrics.NoCandidate(metrics.OperationCreate, 1)
+	}
+}
@@ -0,0 +1,112 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
+	"AvitoTech/pkg/logger"
+	"AvitoTech/pkg/validator"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const maxDeclineReasonLength = 500
+
+// DeclineReview снимает ревьювера с PR по его собственному отказу и
+// назначает замену по правилам ReassignReviewer. Отказ запоминается, и
+// отказавшийся больше не назначается на этот PR. Если замены нет, ревьювер
+// всё равно снимается, ReplacedBy пуст, а PR может стать understaffed
+func (s *Service) DeclineReview(ctx context.Context, req dto.DeclineReviewRequest) (_ *dto.ReassignPullRequestResponse, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.DeclineReview")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
+		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
+	}
+	if err := validator.ValidateUserID(req.ReviewerID); err != nil {
+		return nil, fmt.Errorf("invalid reviewer_id: %w", err)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > maxDeclineReasonLength {
+		return nil, ErrInvalidReason
+	}
+
+	logger.FromContext(ctx).Info("Отказ от ревью",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("reviewer_id", req.ReviewerID),
+	)
+
+	var (
+		newReviewer string
+		updatedPR   *dto.PullRequestDTO
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		pr, reviewer, settings, err := s.lockAssignment(ctx, req.PullRequestID, req.ReviewerID, ActionDecline)
+		if err != nil {
+			return err
+		}
+
+		if err := s.prRepo.RecordDecline(ctx, req.PullRequestID, req.ReviewerID, reason); err != nil {
+			logger.FromContext(ctx).Error("Ошибка при сохранении отказа от ревью", zap.Error(err))
+			return fmt.Errorf("ошибка при сохранении отказа: %w", err)
+		}
+
+		// отказ и снятие ревьювера сохраняются, даже если заменить его некем
+		removed, err := s.prRepo.RemoveReviewer(ctx, req.PullRequestID, req.ReviewerID)
+		if err != nil {
+			logger.FromContext(ctx).Error("Ошибка при удалении ревьювера", zap.Error(err))
+			return fmt.Errorf("ошибка при удалении ревьювера: %w", err)
+		}
+		if !removed {
+			return ErrNotAssigned
+		}
+
+		newReviewer, err = s.findReplacementCandidate(ctx, *settings, reviewer.TeamName, pr, req.ReviewerID)
+		switch {
+		case errors.Is(err, ErrNoCandidate):
+			logger.FromContext(ctx).Warn("Не найден кандидат для замены, ревьювер снят без замены",
+				zap.String("team_name", reviewer.TeamName),
+				zap.String("pr_id", req.PullRequestID),
+			)
+			newReviewer = ""
+		case err != nil:
+			return err
+		default:
+			if err := s.prRepo.AddReviewer(ctx, req.PullRequestID, newReviewer); err != nil {
+				logger.FromContext(ctx).Error("Ошибка при добавлении нового ревьювера", zap.Error(err))
+				return fmt.Errorf("ошибка при добавлении ревьювера: %w", err)
+			}
+		}
+
+		updatedPR, err = s.prRepo.GetPR(ctx, req.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении обновленного PR: %w", err)
+		}
+		return s.updateStaffing(ctx, updatedPR, *settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if newReviewer == "" {
+		metrics.NoCandidate(metrics.OperationDecline, 1)
+	} else {
+		metrics.ReviewersReassigned(metrics.ReassignDeclined, 1)
+	}
+
+	logger.FromContext(ctx).Info("Ревьювер отказался от ревью",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("old_reviewer", req.ReviewerID),
+		zap.String("new_reviewer", newReviewer),
+	)
+
+	return &dto.ReassignPullRequestResponse{
+		PR:         *updatedPR,
+		ReplacedBy: newReviewer,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestDeclineReview(t *testing.T) {
+	tests := []struct {
+		name             string
+		members          []dto.UserDTO
+		wantReplacedBy   string
+		wantReviewers    []string
+		wantUnderstaffed bool
+	}{
+		{
+			name:           "replacement found",
+			members:        []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4")},
+			wantReplacedBy: "u4",
+			wantReviewers:  []string{"u3", "u4"},
+		},
+		{
+			name:             "no replacement",
+			members:          []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), {UserID: "u4"}},
+			wantReviewers:    []string{"u3"},
+			wantUnderstaffed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo()
+			repo.addTeam(backendSettings(), tt.members...)
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            dto.StatusOpen,
+				AssignedReviewers: []string{"u2", "u3"},
+			})
+
+			svc, tx := newTestService(t, repo, 0)
+			resp, err := svc.DeclineReview(context.Background(), dto.DeclineReviewRequest{
+				PullRequestID: "pr-1",
+				ReviewerID:    "u2",
+				Reason:        "в отпуске",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.ReplacedBy != tt.wantReplacedBy {
+				t.Errorf("replaced by %q, want %q", resp.ReplacedBy, tt.wantReplacedBy)
+			}
+			if !slices.Equal(resp.PR.AssignedReviewers, tt.wantReviewers) {
+				t.Errorf("reviewers %v, want %v", resp.PR.AssignedReviewers, tt.wantReviewers)
+			}
+			if resp.PR.Understaffed != tt.wantUnderstaffed {
+				t.Errorf("understaffed %v, want %v", resp.PR.Understaffed, tt.wantUnderstaffed)
+			}
+			if !slices.Contains(repo.declines["pr-1"], "u2") {
+				t.Errorf("decline was not recorded")
+			}
+			if tx.Committed != 1 || tx.RolledBack != 0 {
+				t.Errorf("committed %d, rolled back %d; want 1 and 0", tx.Committed, tx.RolledBack)
+			}
+		})
+	}
+}
+
+func TestDeclineReviewLocksAuthorTeam(t *testing.T) {
+	repo := newMemRepo()
+	repo.addTeam(backendSettings(), activeUser("u1"), activeUser("u2"))
+	repo.addTeam(dto.TeamSettingsDTO{TeamName: "frontend"}, activeUser("f1"), activeUser("f2"))
+	repo.addPR(dto.PullRequestDTO{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            dto.StatusOpen,
+		AssignedReviewers: []string{"u2", "f1"},
+	})
+
+	svc, _ := newTestService(t, repo, 0)
+	resp, err := svc.DeclineReview(context.Background(), dto.DeclineReviewRequest{
+		PullRequestID: "pr-1",
+		ReviewerID:    "f1",
+		Reason:        "не моя область",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ReplacedBy != "f2" {
+		t.Errorf("replaced by %q, want f2", resp.ReplacedBy)
+	}
+	if want := []string{"backend"}; !slices.Equal(repo.locks, want) {
+		t.Errorf("locked %v, want %v", repo.locks, want)
+	}
+}
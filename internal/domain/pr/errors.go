@@ -3,13 +3,23 @@ package pr
 import "errors"
 
 const (
-	PRExists      = "PR_EXISTS"
-	PRMerged      = "PR_MERGED"
-	NotAssigned   = "NOT_ASSIGNED"
-	NoCandidate   = "NO_CANDIDATE"
-	NotFound      = "NOT_FOUND"
-	BadRequest    = "BAD_REQUEST"
-	InternalError = "INTERNAL_ERROR"
+	PRExists           = "PR_EXISTS"
+	PRMerged           = "PR_MERGED"
+	NotAssigned        = "NOT_ASSIGNED"
+	NoCandidate        = "NO_CANDIDATE"
+	NotEnough          = "NOT_ENOUGH_REVIEWERS"
+	InvalidState       = "INVALID_TRANSITION"
+	NotApproved        = "NOT_APPROVED"
+	ReviewerInactive   = "REVIEWER_INACTIVE"
+	ReviewerIsAuthor   = "REVIEWER_IS_AUTHOR"
+	AlreadyAssigned    = "ALREADY_ASSIGNED"
+	TooManyReviewers   = "TOO_MANY_REVIEWERS"
+	ReviewerOtherTeam  = "REVIEWER_OTHER_TEAM"
+	ReviewerDeclined   = "REVIEWER_DECLINED"
+	ReviewerAtCapacity = "REVIEWER_AT_CAPACITY"
+	NotFound           = "NOT_FOUND"
+	BadRequest         = "BAD_REQUEST"
+	InternalError      = "INTERNAL_ERROR"
 )
 
 var (
@@ -19,4 +29,20 @@ var (
 	ErrNoCandidate    = errors.New("no active replacement candidate in team")
 	ErrPRNotFound     = errors.New("pull request not found")
 	ErrAuthorNotFound = errors.New("author not found")
+	// ErrInvalidTransition совпадает с любым *TransitionError
+	ErrInvalidTransition = errors.New("invalid pull request state transition")
+	ErrInvalidVerdict    = errors.New("verdict must be one of APPROVED, CHANGES_REQUESTED, COMMENTED")
+	ErrNotApproved       = errors.New("pull request is not approved")
+	ErrInvalidReason     = errors.New("decline reason must be 1 to 500 characters")
+
+	ErrReviewerNotFound   = errors.New("reviewer not found")
+	ErrReviewerInactive   = errors.New("reviewer is not active")
+	ErrReviewerIsAuthor   = errors.New("author cannot review own pull request")
+	ErrAlreadyAssigned    = errors.New("reviewer is already assigned to this PR")
+	ErrTooManyReviewers   = errors.New("team max reviewer count reached")
+	ErrReviewerOtherTeam  = errors.New("reviewer is not a member of the author's team")
+	ErrReviewerDeclined   = errors.New("reviewer has declined this PR")
+	ErrReviewerAtCapacity = errors.New("reviewer has reached the open review limit")
+
+	ErrNotEnoughReviewers = errors.New("not enough active reviewers in team")
 )
@@ -0,0 +1,241 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/internal/infrastructure/postgres/postgrestest"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var errNotFound = errors.New("not found")
+
+// memRepo - хранилище в памяти для тестов сервиса. Реализует методы
+// репозиториев, которые вызывают тестируемые сценарии; вызов остальных
+// паникует на встроенном nil-интерфейсе
+type memRepo struct {
+	interfaces.PRRepository
+	interfaces.UserRepository
+	interfaces.TeamRepository
+
+	users      map[string]dto.UserDTO
+	settings   map[string]dto.TeamSettingsDTO
+	prs        map[string]*dto.PullRequestDTO
+	reviews    map[string][]dto.ReviewDTO
+	declines   map[string][]string
+	loads      map[string]int
+	capacities map[string]int
+	busyTeams  map[string]bool
+	// locks - команды в порядке взятия блокировок
+	locks []string
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{
+		users:      make(map[string]dto.UserDTO),
+		settings:   make(map[string]dto.TeamSettingsDTO),
+		prs:        make(map[string]*dto.PullRequestDTO),
+		reviews:    make(map[string][]dto.ReviewDTO),
+		declines:   make(map[string][]string),
+		loads:      make(map[string]int),
+		capacities: make(map[string]int),
+		busyTeams:  make(map[string]bool),
+	}
+}
+
+func (r *memRepo) addTeam(settings dto.TeamSettingsDTO, users ...dto.UserDTO) {
+	r.settings[settings.TeamName] = settings
+	for _, user := range users {
+		user.TeamName = settings.TeamName
+		r.users[user.UserID] = user
+	}
+}
+
+func (r *memRepo) addPR(pr dto.PullRequestDTO) {
+	r.prs[pr.PullRequestID] = &pr
+}
+
+func (r *memRepo) GetPR(_ context.Context, prID string) (*dto.PullRequestDTO, error) {
+	pr, ok := r.prs[prID]
+	if !ok {
+		return nil, errNotFound
+	}
+	copied := *pr
+	copied.AssignedReviewers = slices.Clone(pr.AssignedReviewers)
+	return &copied, nil
+}
+
+func (r *memRepo) TransitionStatus(_ context.Context, prID, from, to string) (bool, error) {
+	pr, ok := r.prs[prID]
+	if !ok || pr.Status != from {
+		return false, nil
+	}
+	pr.Status = to
+	return true, nil
+}
+
+func (r *memRepo) SetUnderstaffed(_ context.Context, prID string, understaffed bool) error {
+	r.prs[prID].Understaffed = understaffed
+	return nil
+}
+
+func (r *memRepo) AddReviewer(_ context.Context, prID, reviewerID string) error {
+	r.prs[prID].AssignedReviewers = append(r.prs[prID].AssignedReviewers, reviewerID)
+	return nil
+}
+
+func (r *memRepo) RemoveReviewer(_ context.Context, prID, reviewerID string) (bool, error) {
+	pr := r.prs[prID]
+	before := len(pr.AssignedReviewers)
+	pr.AssignedReviewers = slices.DeleteFunc(pr.AssignedReviewers, func(userID string) bool {
+		return userID == reviewerID
+	})
+	return len(pr.AssignedReviewers) < before, nil
+}
+
+func (r *memRepo) IsReviewerAssigned(_ context.Context, prID, reviewerID string) (bool, error) {
+	pr, ok := r.prs[prID]
+	return ok && slices.Contains(pr.AssignedReviewers, reviewerID), nil
+}
+
+func (r *memRepo) GetReviews(_ context.Context, prID string) ([]dto.ReviewDTO, error) {
+	return r.reviews[prID], nil
+}
+
+func (r *memRepo) RecordDecline(_ context.Context, prID, reviewerID, _ string) error {
+	r.declines[prID] = append(r.declines[prID], reviewerID)
+	return nil
+}
+
+func (r *memRepo) GetDeclinedReviewers(_ context.Context, prID string) ([]string, error) {
+	return r.declines[prID], nil
+}
+
+func (r *memRepo) GetOpenReviewCounts(_ context.Context, userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	for _, userID := range userIDs {
+		counts[userID] = r.loads[userID]
+	}
+	return counts, nil
+}
+
+func (r *memRepo) GetOpenReviewsByReviewers(_ context.Context, reviewerIDs []string) ([]dto.OpenReviewDTO, error) {
+	prIDs := slices.Sorted(maps.Keys(r.prs))
+
+	var reviews []dto.OpenReviewDTO
+	for _, reviewerID := range reviewerIDs {
+		for _, prID := range prIDs {
+			pr := r.prs[prID]
+			if pr.Status != dto.StatusOpen || !slices.Contains(pr.AssignedReviewers, reviewerID) {
+				continue
+			}
+			reviews = append(reviews, dto.OpenReviewDTO{
+				PullRequestID: pr.PullRequestID,
+				AuthorID:      pr.AuthorID,
+				AuthorTeam:    r.users[pr.AuthorID].TeamName,
+				ReviewerID:    reviewerID,
+				Reviewers:     slices.Clone(pr.AssignedReviewers),
+				Declined:      r.declines[prID],
+			})
+		}
+	}
+	return reviews, nil
+}
+
+func (r *memRepo) GetUser(_ context.Context, userID string) (*dto.UserDTO, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &user, nil
+}
+
+func (r *memRepo) GetTeamByName(_ context.Context, teamName string) (*dto.TeamDTO, error) {
+	team := &dto.TeamDTO{TeamName: teamName}
+	for _, user := range r.sortedUsers() {
+		if user.TeamName == teamName {
+			team.Members = append(team.Members, dto.TeamMemberDTO{UserID: user.UserID, IsActive: user.IsActive})
+		}
+	}
+	return team, nil
+}
+
+func (r *memRepo) GetActiveUsersOutsideTeam(_ context.Context, teamName string) ([]dto.UserDTO, error) {
+	var users []dto.UserDTO
+	for _, user := range r.sortedUsers() {
+		if user.IsActive && user.TeamName != teamName {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *memRepo) GetReviewCapacities(_ context.Context, userIDs []string) (map[string]int, error) {
+	capacities := make(map[string]int)
+	for _, userID := range userIDs {
+		if limit, ok := r.capacities[userID]; ok {
+			capacities[userID] = limit
+		}
+	}
+	return capacities, nil
+}
+
+func (r *memRepo) GetTeamSettings(_ context.Context, name string) (*dto.TeamSettingsDTO, error) {
+	settings, ok := r.settings[name]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &settings, nil
+}
+
+func (r *memRepo) LockTeam(_ context.Context, name string) error {
+	r.locks = append(r.locks, name)
+	return nil
+}
+
+func (r *memRepo) TryLockTeam(_ context.Context, name string) (bool, error) {
+	return !r.busyTeams[name], nil
+}
+
+func (r *memRepo) sortedUsers() []dto.UserDTO {
+	users := make([]dto.UserDTO, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	slices.SortFunc(users, func(a, b dto.UserDTO) int {
+		return strings.Compare(a.UserID, b.UserID)
+	})
+	return users
+}
+
+func newTestService(t *testing.T, repo *memRepo, maxOpenReviews int) (*Service, *postgrestest.FakeTxManager) {
+	t.Helper()
+
+	tx := postgrestest.NewFakeTxManager()
+	svc, err := NewService(repo, repo, repo, tx, SelectorConfig{
+		DefaultStrategy: dto.StrategyRoundRobin,
+		MaxOpenReviews:  maxOpenReviews,
+	})
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return svc, tx
+}
+
+func backendSettings() dto.TeamSettingsDTO {
+	return dto.TeamSettingsDTO{
+		TeamName:          "backend",
+		RequiredReviewers: 2,
+		MinReviewers:      0,
+		RequiredApprovals: 1,
+		MaxReviewers:      3,
+	}
+}
+
+func activeUser(userID string) dto.UserDTO {
+	return dto.UserDTO{UserID: userID, IsActive: true}
+}
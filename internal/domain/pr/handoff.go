@@ -0,0 +1,280 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/tracing"
+	"AvitoTech/pkg/logger"
+	"context"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// PlanReviewHandoff подбирает замену уходящим участникам команды на всех их
+// открытых ревью по тем же правилам, что и ReassignReviewer. Данные читаются
+// несколькими пакетными запросами, а нагрузка кандидатов учитывается в памяти,
+// чтобы одна передача не сваливала все ревью на одного человека.
+// Изменения не сохраняются. Внутри транзакции команда блокируется до её
+// завершения, поэтому план нужно применять в той же транзакции
+func (s *Service) PlanReviewHandoff(ctx context.Context, teamName string, userIDs []string) (_ *dto.HandoffReportDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.PlanReviewHandoff")
+	defer func() { tracing.End(span, err) }()
+
+	report := &dto.HandoffReportDTO{
+		Reassigned:  []dto.ReviewHandoffDTO{},
+		NoCandidate: []dto.ReviewHandoffDTO{},
+	}
+	if len(userIDs) == 0 {
+		return report, nil
+	}
+
+	if teamName != "" {
+		if err := s.teamRepo.LockTeam(ctx, teamName); err != nil {
+			return nil, err
+		}
+	}
+
+	reviews, err := s.prRepo.GetOpenReviewsByReviewers(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении открытых ревью: %w", err)
+	}
+	if len(reviews) == 0 {
+		return report, nil
+	}
+
+	leaving := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		leaving[userID] = true
+	}
+
+	var teamCandidates []string
+	if teamName != "" {
+		team, err := s.userRepo.GetTeamByName(ctx, teamName)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении команды: %w", err)
+		}
+		for _, member := range team.Members {
+			if member.IsActive && !leaving[member.UserID] {
+				teamCandidates = append(teamCandidates, member.UserID)
+			}
+		}
+	}
+
+	p := &handoffPlanner{
+		service:  s,
+		teamName: teamName,
+		leaving:  leaving,
+		settings: make(map[string]*dto.TeamSettingsDTO),
+		added:    make(map[string][]string),
+	}
+	if err := p.trackLoads(ctx, teamCandidates); err != nil {
+		return nil, err
+	}
+
+	for _, review := range reviews {
+		handoff := dto.ReviewHandoffDTO{
+			PullRequestID: review.PullRequestID,
+			OldUserID:     review.ReviewerID,
+		}
+
+		newReviewer, err := p.pick(ctx, review, teamCandidates)
+		if err != nil {
+			return nil, err
+		}
+		if newReviewer == "" {
+			report.NoCandidate = append(report.NoCandidate, handoff)
+			continue
+		}
+
+		handoff.ReplacedBy = newReviewer
+		report.Reassigned = append(report.Reassigned, handoff)
+	}
+
+	logger.FromContext(ctx).Info("Подготовлена передача ревью",
+		zap.String("team_name", teamName),
+		zap.Int("users", len(userIDs)),
+		zap.Int("reassigned", len(report.Reassigned)),
+		zap.Int("no_candidate", len(report.NoCandidate)),
+	)
+
+	return report, nil
+}
+
+// UpdateHandoffStaffing пересчитывает отметку understaffed на всех PR из
+// отчёта о применённой передаче ревью. Вызывается в той же транзакции, что
+// PlanReviewHandoff и применение плана
+func (s *Service) UpdateHandoffStaffing(ctx context.Context, teamName string, report *dto.HandoffReportDTO) (err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.UpdateHandoffStaffing")
+	defer func() { tracing.End(span, err) }()
+
+	updated := make(map[string]bool)
+	settings := make(map[string]*dto.TeamSettingsDTO)
+	for _, handoff := range slices.Concat(report.Reassigned, report.NoCandidate) {
+		if updated[handoff.PullRequestID] {
+			continue
+		}
+		updated[handoff.PullRequestID] = true
+
+		pr, err := s.prRepo.GetPR(ctx, handoff.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении PR: %w", err)
+		}
+
+		authorSettings, ok := settings[pr.AuthorID]
+		if !ok {
+			authorSettings, err = s.settingsForPR(ctx, pr.AuthorID, teamName)
+			if err != nil {
+				return err
+			}
+			settings[pr.AuthorID] = authorSettings
+		}
+
+		if err := s.updateStaffing(ctx, pr, *authorSettings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type handoffPlanner struct {
+	service  *Service
+	teamName string
+	leaving  map[string]bool
+
+	settings map[string]*dto.TeamSettingsDTO
+	loads    map[string]int
+	// limits - лимиты открытых ревью кандидатов, 0 - без лимита
+	limits map[string]int
+	// added - уже запланированные новые ревьюверы по PR, чтобы не назначить
+	// одного человека дважды, если с PR уходят несколько ревьюверов
+	added     map[string][]string
+	outsiders []string
+	// outsidersLoaded отличает "ещё не загружали" от "загрузили пустой список"
+	outsidersLoaded bool
+}
+
+func (p *handoffPlanner) trackLoads(ctx context.Context, userIDs []string) error {
+	if p.loads == nil {
+		p.loads = make(map[string]int)
+		p.limits = make(map[string]int)
+	}
+
+	var missing []string
+	for _, userID := range userIDs {
+		if _, ok := p.loads[userID]; !ok {
+			missing = append(missing, userID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	loads, err := p.service.prRepo.GetOpenReviewCounts(ctx, missing)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении нагрузки ревьюверов: %w", err)
+	}
+	limits, err := p.service.reviewLimits(ctx, missing)
+	if err != nil {
+		return err
+	}
+	for _, userID := range missing {
+		p.loads[userID] = loads[userID]
+		p.limits[userID] = limits[userID]
+	}
+	return nil
+}
+
+func (p *handoffPlanner) settingsFor(ctx context.Context, review dto.OpenReviewDTO) (*dto.TeamSettingsDTO, error) {
+	teamName := review.AuthorTeam
+	if teamName == "" {
+		teamName = p.teamName
+	}
+	if settings, ok := p.settings[teamName]; ok {
+		return settings, nil
+	}
+
+	settings, err := p.service.teamRepo.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении настроек команды: %w", err)
+	}
+	p.settings[teamName] = settings
+	return settings, nil
+}
+
+func (p *handoffPlanner) pick(ctx context.Context, review dto.OpenReviewDTO, teamCandidates []string) (string, error) {
+	if p.teamName == "" {
+		return "", nil
+	}
+
+	settings, err := p.settingsFor(ctx, review)
+	if err != nil {
+		return "", err
+	}
+
+	exclude := make(map[string]bool, len(review.Reviewers)+len(review.Declined)+1)
+	exclude[review.AuthorID] = true
+	for _, reviewerID := range review.Reviewers {
+		exclude[reviewerID] = true
+	}
+	for _, reviewerID := range review.Declined {
+		exclude[reviewerID] = true
+	}
+	for _, reviewerID := range p.added[review.PullRequestID] {
+		exclude[reviewerID] = true
+	}
+
+	candidates := p.filter(teamCandidates, exclude)
+	if len(candidates) == 0 && settings.AllowCrossTeamFallback {
+		outsiders, err := p.loadOutsiders(ctx)
+		if err != nil {
+			return "", err
+		}
+		candidates = p.filter(outsiders, exclude)
+	}
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	selected := p.service.selectWithLoads(ctx, *settings, candidates, p.loads, 1)
+	if len(selected) == 0 {
+		return "", nil
+	}
+
+	newReviewer := selected[0]
+	p.loads[newReviewer]++
+	p.added[review.PullRequestID] = append(p.added[review.PullRequestID], newReviewer)
+	return newReviewer, nil
+}
+
+func (p *handoffPlanner) filter(userIDs []string, exclude map[string]bool) []string {
+	result := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if exclude[userID] || p.leaving[userID] {
+			continue
+		}
+		if limit := p.limits[userID]; limit > 0 && p.loads[userID] >= limit {
+			continue
+		}
+		result = append(result, userID)
+	}
+	return result
+}
+
+func (p *handoffPlanner) loadOutsiders(ctx context.Context) ([]string, error) {
+	if p.outsidersLoaded {
+		return p.outsiders, nil
+	}
+
+	outsiders, err := p.service.lockedOutsiders(ctx, p.teamName)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.trackLoads(ctx, outsiders); err != nil {
+		return nil, err
+	}
+
+	p.outsiders = outsiders
+	p.outsidersLoaded = true
+	return outsiders, nil
+}
@@ -0,0 +1,203 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestPlanReviewHandoff(t *testing.T) {
+	tests := []struct {
+		name            string
+		teamName        string
+		crossTeam       bool
+		members         []dto.UserDTO
+		limit           int
+		declined        []string
+		leaving         []string
+		wantReassigned  []dto.ReviewHandoffDTO
+		wantNoCandidate []dto.ReviewHandoffDTO
+	}{
+		{
+			name:     "teammates in round-robin order",
+			teamName: "backend",
+			members:  []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4"), activeUser("u5")},
+			leaving:  []string{"u2"},
+			wantReassigned: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-1", OldUserID: "u2", ReplacedBy: "u4"},
+				{PullRequestID: "pr-2", OldUserID: "u2", ReplacedBy: "u5"},
+			},
+			wantNoCandidate: []dto.ReviewHandoffDTO{},
+		},
+		{
+			name:           "no active teammates",
+			teamName:       "backend",
+			members:        []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), {UserID: "u4"}},
+			leaving:        []string{"u2"},
+			wantReassigned: []dto.ReviewHandoffDTO{},
+			wantNoCandidate: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-1", OldUserID: "u2"},
+				{PullRequestID: "pr-2", OldUserID: "u2"},
+			},
+		},
+		{
+			name:     "candidate at capacity after the first review",
+			teamName: "backend",
+			members:  []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4")},
+			limit:    1,
+			leaving:  []string{"u2"},
+			wantReassigned: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-1", OldUserID: "u2", ReplacedBy: "u4"},
+			},
+			wantNoCandidate: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-2", OldUserID: "u2"},
+			},
+		},
+		{
+			name:     "declined teammate is skipped",
+			teamName: "backend",
+			members:  []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4")},
+			declined: []string{"u4"},
+			leaving:  []string{"u2"},
+			wantReassigned: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-2", OldUserID: "u2", ReplacedBy: "u4"},
+			},
+			wantNoCandidate: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-1", OldUserID: "u2"},
+			},
+		},
+		{
+			name:      "cross-team fallback",
+			teamName:  "backend",
+			crossTeam: true,
+			members:   []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3")},
+			leaving:   []string{"u2"},
+			wantReassigned: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-1", OldUserID: "u2", ReplacedBy: "f1"},
+				{PullRequestID: "pr-2", OldUserID: "u2", ReplacedBy: "f1"},
+			},
+			wantNoCandidate: []dto.ReviewHandoffDTO{},
+		},
+		{
+			name:     "two leaving reviewers of one PR get different replacements",
+			teamName: "backend",
+			members:  []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4"), activeUser("u5")},
+			leaving:  []string{"u2", "u3"},
+			wantReassigned: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-1", OldUserID: "u2", ReplacedBy: "u4"},
+				{PullRequestID: "pr-2", OldUserID: "u2", ReplacedBy: "u5"},
+				{PullRequestID: "pr-1", OldUserID: "u3", ReplacedBy: "u5"},
+				{PullRequestID: "pr-2", OldUserID: "u3", ReplacedBy: "u4"},
+			},
+			wantNoCandidate: []dto.ReviewHandoffDTO{},
+		},
+		{
+			name:           "user without a team",
+			members:        []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4")},
+			leaving:        []string{"u2"},
+			wantReassigned: []dto.ReviewHandoffDTO{},
+			wantNoCandidate: []dto.ReviewHandoffDTO{
+				{PullRequestID: "pr-1", OldUserID: "u2"},
+				{PullRequestID: "pr-2", OldUserID: "u2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo()
+			settings := backendSettings()
+			settings.AllowCrossTeamFallback = tt.crossTeam
+			repo.addTeam(settings, tt.members...)
+			repo.addTeam(dto.TeamSettingsDTO{TeamName: "frontend"}, activeUser("f1"))
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            dto.StatusOpen,
+				AssignedReviewers: []string{"u2", "u3"},
+			})
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-2",
+				AuthorID:          "u1",
+				Status:            dto.StatusOpen,
+				AssignedReviewers: []string{"u2", "u3"},
+			})
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-3",
+				AuthorID:          "u1",
+				Status:            dto.StatusMerged,
+				AssignedReviewers: []string{"u2"},
+			})
+			repo.declines["pr-1"] = tt.declined
+
+			svc, _ := newTestService(t, repo, tt.limit)
+			report, err := svc.PlanReviewHandoff(context.Background(), tt.teamName, tt.leaving)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(report.Reassigned, tt.wantReassigned) {
+				t.Errorf("reassigned %v, want %v", report.Reassigned, tt.wantReassigned)
+			}
+			if !slices.Equal(report.NoCandidate, tt.wantNoCandidate) {
+				t.Errorf("no candidate %v, want %v", report.NoCandidate, tt.wantNoCandidate)
+			}
+			if got := repo.prs["pr-1"].AssignedReviewers; !slices.Equal(got, []string{"u2", "u3"}) {
+				t.Errorf("plan changed reviewers of pr-1 to %v", got)
+			}
+		})
+	}
+}
+
+func TestUpdateHandoffStaffing(t *testing.T) {
+	tests := []struct {
+		name             string
+		reviewers        []string
+		understaffed     bool
+		report           dto.HandoffReportDTO
+		wantUnderstaffed bool
+	}{
+		{
+			name:             "reassigned PR with enough reviewers is cleared",
+			reviewers:        []string{"u3", "u4"},
+			understaffed:     true,
+			report:           dto.HandoffReportDTO{Reassigned: []dto.ReviewHandoffDTO{{PullRequestID: "pr-1", OldUserID: "u2", ReplacedBy: "u4"}}},
+			wantUnderstaffed: false,
+		},
+		{
+			name:             "no-candidate PR without enough reviewers is marked",
+			reviewers:        []string{"u3"},
+			report:           dto.HandoffReportDTO{NoCandidate: []dto.ReviewHandoffDTO{{PullRequestID: "pr-1", OldUserID: "u2"}}},
+			wantUnderstaffed: true,
+		},
+		{
+			name:             "PR outside the report is not touched",
+			reviewers:        []string{"u3"},
+			report:           dto.HandoffReportDTO{},
+			wantUnderstaffed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo()
+			repo.addTeam(backendSettings(), activeUser("u1"), activeUser("u3"), activeUser("u4"))
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            dto.StatusOpen,
+				AssignedReviewers: tt.reviewers,
+				Understaffed:      tt.understaffed,
+			})
+
+			svc, _ := newTestService(t, repo, 0)
+			if err := svc.UpdateHandoffStaffing(context.Background(), "backend", &tt.report); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := repo.prs["pr-1"].Understaffed; got != tt.wantUnderstaffed {
+				t.Errorf("understaffed %v, want %v", got, tt.wantUnderstaffed)
+			}
+		})
+	}
+}
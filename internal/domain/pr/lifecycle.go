@@ -0,0 +1,228 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
+	"AvitoTech/pkg/logger"
+	"AvitoTech/pkg/validator"
+	"context"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// ReadyPR переводит черновик в OPEN и назначает ревьюверов
+func (s *Service) ReadyPR(ctx context.Context, req dto.PullRequestActionRequest) (_ *dto.PullRequestDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.ReadyPR")
+	defer func() { tracing.End(span, err) }()
+
+	return s.openPR(ctx, req, ActionReady)
+}
+
+// ReopenPR возвращает закрытый PR в OPEN. Вердикты сбрасываются, прежние
+// ревьюверы остаются, если они активны и не исчерпали лимит ревью;
+// недостающие назначаются заново
+func (s *Service) ReopenPR(ctx context.Context, req dto.PullRequestActionRequest) (_ *dto.PullRequestDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.ReopenPR")
+	defer func() { tracing.End(span, err) }()
+
+	return s.openPR(ctx, req, ActionReopen)
+}
+
+// ClosePR отклоняет черновик или открытый PR. Назначения ревьюверов
+// остаются, но в нагрузке закрытые PR не учитываются
+func (s *Service) ClosePR(ctx context.Context, req dto.PullRequestActionRequest) (_ *dto.PullRequestDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.ClosePR")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
+		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("Закрытие PR", zap.String("pr_id", req.PullRequestID))
+
+	pr, err := s.prRepo.GetPR(ctx, req.PullRequestID)
+	if err != nil {
+		logger.FromContext(ctx).Error("PR не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
+		return nil, ErrPRNotFound
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		status, err := s.transition(ctx, pr, ActionClose)
+		if err != nil {
+			return err
+		}
+		pr.Status = status
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.PRTransition(ActionClose)
+	logger.FromContext(ctx).Info("PR закрыт", zap.String("pr_id", req.PullRequestID))
+
+	return pr, nil
+}
+
+func (s *Service) openPR(ctx context.Context, req dto.PullRequestActionRequest, action string) (*dto.PullRequestDTO, error) {
+	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
+		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("Открытие PR",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("action", action),
+	)
+
+	pr, err := s.prRepo.GetPR(ctx, req.PullRequestID)
+	if err != nil {
+		logger.FromContext(ctx).Error("PR не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
+		return nil, ErrPRNotFound
+	}
+
+	// недопустимый переход отсекаем до чтения настроек команды
+	if _, err := nextStatus(pr, action); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.authorSettings(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := false
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.LockTeam(ctx, settings.TeamName); err != nil {
+			return err
+		}
+
+		// прежние ревьюверы проверяются до перехода, пока этот PR не входит в
+		// их нагрузку
+		kept, err := s.keepReviewers(ctx, pr)
+		if err != nil {
+			return err
+		}
+
+		status, err := s.transition(ctx, pr, action)
+		if err != nil {
+			return err
+		}
+		pr.Status = status
+
+		if action == ActionReopen {
+			// одобрения, полученные до закрытия, не дают смерджить PR без
+			// нового ревью
+			if err := s.prRepo.ResetReviewVerdicts(ctx, pr.PullRequestID); err != nil {
+				return err
+			}
+		}
+
+		for _, reviewerID := range pr.AssignedReviewers {
+			if !slices.Contains(kept, reviewerID) {
+				if _, err := s.prRepo.RemoveReviewer(ctx, pr.PullRequestID, reviewerID); err != nil {
+					return err
+				}
+			}
+		}
+		pr.AssignedReviewers = kept
+
+		missing := settings.RequiredReviewers - len(kept)
+		if missing <= 0 {
+			return s.updateStaffing(ctx, pr, *settings)
+		}
+
+		declined, err := s.prRepo.GetDeclinedReviewers(ctx, pr.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении отказов от ревью: %w", err)
+		}
+
+		// доназначаются только недостающие ревьюверы
+		topUp := *settings
+		topUp.RequiredReviewers = missing
+		topUp.MinReviewers = max(settings.MinReviewers-len(kept), 0)
+		reviewers, err := s.assignReviewers(ctx, pr.AuthorID, topUp, append(declined, kept...))
+		if err != nil {
+			logger.FromContext(ctx).Error("Ошибка при автоназначении ревьюверов", zap.Error(err))
+			return fmt.Errorf("ошибка при назначении ревьюверов: %w", err)
+		}
+		if len(reviewers) > 0 {
+			if err := s.prRepo.AssignReviewers(ctx, pr.PullRequestID, reviewers); err != nil {
+				logger.FromContext(ctx).Error("Ошибка при назначении ревьюверов в БД", zap.Error(err))
+				return fmt.Errorf("ошибка при назначении ревьюверов: %w", err)
+			}
+		}
+		pr.AssignedReviewers = append(kept, reviewers...)
+		assigned = true
+		return s.updateStaffing(ctx, pr, *settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.PRTransition(action)
+	if assigned {
+		s.observeAssigned(len(pr.AssignedReviewers), pr.Understaffed, *settings)
+	}
+
+	logger.FromContext(ctx).Info("PR открыт",
+		zap.String("pr_id", pr.PullRequestID),
+		zap.String("action", action),
+		zap.Strings("reviewers", pr.AssignedReviewers),
+	)
+
+	return pr, nil
+}
+
+// keepReviewers возвращает ревьюверов PR, которые остаются на нём при
+// открытии: активных и не исчерпавших лимит открытых ревью
+func (s *Service) keepReviewers(ctx context.Context, pr *dto.PullRequestDTO) ([]string, error) {
+	active := []string{}
+	for _, reviewerID := range pr.AssignedReviewers {
+		reviewer, err := s.userRepo.GetUser(ctx, reviewerID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении ревьювера: %w", err)
+		}
+		if reviewer.IsActive {
+			active = append(active, reviewerID)
+		}
+	}
+
+	kept, _, err := s.withinCapacity(ctx, active)
+	if err != nil {
+		return nil, err
+	}
+	if len(kept) < len(pr.AssignedReviewers) {
+		logger.FromContext(ctx).Info("Неактивные или перегруженные ревьюверы сняты с PR",
+			zap.String("pr_id", pr.PullRequestID),
+			zap.Strings("kept", kept),
+		)
+	}
+	return kept, nil
+}
+
+// transition применяет действие к PR условным UPDATE. Если статус успели
+// изменить параллельно, ошибка строится по фактическому статусу
+func (s *Service) transition(ctx context.Context, pr *dto.PullRequestDTO, action string) (string, error) {
+	to, err := nextStatus(pr, action)
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := s.prRepo.TransitionStatus(ctx, pr.PullRequestID, pr.Status, to)
+	if err != nil {
+		logger.FromContext(ctx).Error("Ошибка при обновлении статуса PR", zap.Error(err))
+		return "", fmt.Errorf("ошибка при обновлении статуса: %w", err)
+	}
+	if ok {
+		return to, nil
+	}
+
+	current, err := s.prRepo.GetPR(ctx, pr.PullRequestID)
+	if err != nil {
+		return "", ErrPRNotFound
+	}
+	return "", &TransitionError{PullRequestID: pr.PullRequestID, From: current.Status, Action: action}
+}
@@ -2,6 +2,8 @@ package pr
 
 import (
 	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
 	"AvitoTech/pkg/logger"
 	"AvitoTech/pkg/validator"
 	"context"
@@ -11,21 +13,24 @@ import (
 	"go.uber.org/zap"
 )
 
-func (s *Service) MergePR(ctx context.Context, req dto.MergePullRequestRequest) (*dto.MergedPullRequestDTO, error) {
+func (s *Service) MergePR(ctx context.Context, req dto.MergePullRequestRequest) (_ *dto.MergedPullRequestDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.MergePR")
+	defer func() { tracing.End(span, err) }()
+
 	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
 		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
 	}
 
-	logger.Log.Info("Мердж PR", zap.String("pr_id", req.PullRequestID))
+	logger.FromContext(ctx).Info("Мердж PR", zap.String("pr_id", req.PullRequestID))
 
 	pr, err := s.prRepo.GetPR(ctx, req.PullRequestID)
 	if err != nil {
-		logger.Log.Error("PR не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
+		logger.FromContext(ctx).Error("PR не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
 		return nil, ErrPRNotFound
 	}
 
 	if pr.Status == dto.StatusMerged {
-		logger.Log.Info("PR уже смерджен", zap.String("pr_id", req.PullRequestID))
+		logger.FromContext(ctx).Info("PR уже смерджен", zap.String("pr_id", req.PullRequestID))
 
 		mergedAt := time.Now().Format(time.RFC3339)
 
@@ -39,19 +44,46 @@ func (s *Service) MergePR(ctx context.Context, req dto.MergePullRequestRequest)
 		}, nil
 	}
 
-	if err := s.prRepo.UpdatePRStatus(ctx, req.PullRequestID, dto.StatusMerged); err != nil {
-		logger.Log.Error("Ошибка при обновлении статуса PR", zap.Error(err))
-		return nil, fmt.Errorf("ошибка при обновлении статуса: %w", err)
-	}
+	alreadyMerged := false
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if err := s.lockAuthorTeam(ctx, pr); err != nil {
+			return err
+		}
+
+		// вердикты и статус перечитываются под блокировкой, которую держат
+		// SubmitReview и изменения состава ревьюверов
+		pr, err = s.prRepo.GetPR(ctx, req.PullRequestID)
+		if err != nil {
+			return ErrPRNotFound
+		}
+		if pr.Status == dto.StatusMerged {
+			alreadyMerged = true
+			return nil
+		}
+		if _, err := nextStatus(pr, ActionMerge); err != nil {
+			return err
+		}
 
-	if err := s.prRepo.SetMergedAt(ctx, req.PullRequestID); err != nil {
-		logger.Log.Error("Ошибка при установке времени мерджа", zap.Error(err))
-		return nil, fmt.Errorf("ошибка при установке времени мерджа: %w", err)
+		if req.OverrideApprovals {
+			logger.FromContext(ctx).Warn("Мердж без проверки одобрений", zap.String("pr_id", req.PullRequestID))
+		} else if err := s.checkApproved(ctx, pr); err != nil {
+			return err
+		}
+
+		_, err = s.transition(ctx, pr, ActionMerge)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	if !alreadyMerged {
+		metrics.PRMerged()
+	}
 	mergedAt := time.Now().Format(time.RFC3339)
 
-	logger.Log.Info("PR успешно смерджен",
+	logger.FromContext(ctx).Info("PR успешно смерджен",
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("merged_at", mergedAt),
 	)
@@ -0,0 +1,83 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMergePRApprovalGate(t *testing.T) {
+	approved := dto.ReviewDTO{ReviewerID: "u2", Verdict: dto.VerdictApproved}
+	approved2 := dto.ReviewDTO{ReviewerID: "u3", Verdict: dto.VerdictApproved}
+	changes := dto.ReviewDTO{ReviewerID: "u3", Verdict: dto.VerdictChangesRequested}
+	commented := dto.ReviewDTO{ReviewerID: "u3", Verdict: dto.VerdictCommented}
+	pending := dto.ReviewDTO{ReviewerID: "u3"}
+
+	tests := []struct {
+		name              string
+		requiredApprovals int
+		reviews           []dto.ReviewDTO
+		override          bool
+		wantErr           error
+	}{
+		{name: "no reviews", requiredApprovals: 1, wantErr: ErrNotApproved},
+		{name: "one approval", requiredApprovals: 1, reviews: []dto.ReviewDTO{approved, pending}},
+		{name: "comment does not count", requiredApprovals: 1, reviews: []dto.ReviewDTO{commented}, wantErr: ErrNotApproved},
+		{name: "approval and comment", requiredApprovals: 1, reviews: []dto.ReviewDTO{approved, commented}},
+		{name: "changes requested blocks", requiredApprovals: 1, reviews: []dto.ReviewDTO{approved, changes}, wantErr: ErrNotApproved},
+		{name: "two required, one given", requiredApprovals: 2, reviews: []dto.ReviewDTO{approved, pending}, wantErr: ErrNotApproved},
+		{name: "two required, two given", requiredApprovals: 2, reviews: []dto.ReviewDTO{approved, approved2}},
+		{name: "gate disabled", requiredApprovals: 0},
+		{name: "gate disabled, changes requested", requiredApprovals: 0, reviews: []dto.ReviewDTO{changes}, wantErr: ErrNotApproved},
+		{name: "override", requiredApprovals: 2, reviews: []dto.ReviewDTO{changes}, override: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo()
+			settings := backendSettings()
+			settings.RequiredApprovals = tt.requiredApprovals
+			repo.addTeam(settings, activeUser("u1"), activeUser("u2"), activeUser("u3"))
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            dto.StatusOpen,
+				AssignedReviewers: []string{"u2", "u3"},
+			})
+			repo.reviews["pr-1"] = tt.reviews
+
+			svc, tx := newTestService(t, repo, 0)
+			_, err := svc.MergePR(context.Background(), dto.MergePullRequestRequest{
+				PullRequestID:     "pr-1",
+				OverrideApprovals: tt.override,
+			})
+
+			if want := []string{"backend"}; !slices.Equal(repo.locks, want) {
+				t.Errorf("locked %v, want %v", repo.locks, want)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if repo.prs["pr-1"].Status != dto.StatusOpen {
+					t.Errorf("status changed to %s after rejected merge", repo.prs["pr-1"].Status)
+				}
+				if tx.RolledBack != 1 {
+					t.Errorf("rolled back %d transactions, want 1", tx.RolledBack)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if repo.prs["pr-1"].Status != dto.StatusMerged {
+				t.Errorf("status %s, want MERGED", repo.prs["pr-1"].Status)
+			}
+			if tx.Committed != 1 {
+				t.Errorf("committed %d transactions, want 1", tx.Committed)
+			}
+		})
+	}
+}
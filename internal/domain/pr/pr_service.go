@@ -1,17 +1,95 @@
 package pr
 
 import (
+	"AvitoTech/internal/domain/dto"
 	"AvitoTech/internal/domain/interfaces"
+	"context"
+	"fmt"
 )
 
 type Service struct {
 	prRepo   interfaces.PRRepository
 	userRepo interfaces.UserRepository
+	teamRepo interfaces.TeamRepository
+	tx       interfaces.TxManager
+
+	selectors       map[string]ReviewerSelector
+	defaultStrategy string
+	teamStrategies  map[string]string
+	maxOpenReviews  int
 }
 
-func NewService(prRepo interfaces.PRRepository, userRepo interfaces.UserRepository) *Service {
+func NewService(prRepo interfaces.PRRepository, userRepo interfaces.UserRepository, teamRepo interfaces.TeamRepository, tx interfaces.TxManager, cfg SelectorConfig) (*Service, error) {
+	if cfg.DefaultStrategy == "" {
+		cfg.DefaultStrategy = dto.StrategyRandom
+	}
+
+	selectors := make(map[string]ReviewerSelector, len(dto.Strategies))
+	for _, strategy := range dto.Strategies {
+		sel, err := NewSelector(strategy, cfg.Weights)
+		if err != nil {
+			return nil, err
+		}
+		selectors[strategy] = sel
+	}
+
+	if _, ok := selectors[cfg.DefaultStrategy]; !ok {
+		return nil, fmt.Errorf("unknown reviewer strategy: %s", cfg.DefaultStrategy)
+	}
+
+	teamStrategies := make(map[string]string, len(cfg.TeamStrategies))
+	for teamName, strategy := range cfg.TeamStrategies {
+		if _, ok := selectors[strategy]; !ok {
+			return nil, fmt.Errorf("team %s: unknown reviewer strategy: %s", teamName, strategy)
+		}
+		teamStrategies[teamName] = strategy
+	}
+
 	return &Service{
-		prRepo:   prRepo,
-		userRepo: userRepo,
+		prRepo:          prRepo,
+		userRepo:        userRepo,
+		teamRepo:        teamRepo,
+		tx:              tx,
+		selectors:       selectors,
+		defaultStrategy: cfg.DefaultStrategy,
+		teamStrategies:  teamStrategies,
+		maxOpenReviews:  cfg.MaxOpenReviews,
+	}, nil
+}
+
+// selectorFor выбирает стратегию по приоритету: настройки команды в БД,
+// стратегия команды из конфигурации запуска, стратегия по умолчанию
+func (s *Service) selectorFor(settings dto.TeamSettingsDTO) ReviewerSelector {
+	if sel, ok := s.selectors[settings.Strategy]; ok {
+		return sel
+	}
+	if strategy, ok := s.teamStrategies[settings.TeamName]; ok {
+		return s.selectors[strategy]
+	}
+	return s.selectors[s.defaultStrategy]
+}
+
+func (s *Service) pickReviewers(ctx context.Context, settings dto.TeamSettingsDTO, candidates []string, count int) ([]string, error) {
+	sel := s.selectorFor(settings)
+
+	loadBased, ok := sel.(LoadBasedSelector)
+	if !ok {
+		return sel.Select(ctx, settings.TeamName, candidates, count), nil
+	}
+
+	loads, err := s.prRepo.GetOpenReviewCounts(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении нагрузки ревьюверов: %w", err)
+	}
+	return loadBased.SelectByLoad(candidates, loads, count), nil
+}
+
+// selectWithLoads выбирает ревьюверов по уже известной нагрузке без
+// обращения к репозиторию
+func (s *Service) selectWithLoads(ctx context.Context, settings dto.TeamSettingsDTO, candidates []string, loads map[string]int, count int) []string {
+	sel := s.selectorFor(settings)
+	if loadBased, ok := sel.(LoadBasedSelector); ok {
+		return loadBased.SelectByLoad(candidates, loads, count)
 	}
+	return sel.Select(ctx, settings.TeamName, candidates, count)
 }
@@ -2,15 +2,22 @@ package pr
 
 import (
 	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
 	"AvitoTech/pkg/logger"
 	"AvitoTech/pkg/validator"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 
 	"go.uber.org/zap"
 )
 
-func (s *Service) ReassignReviewer(ctx context.Context, req dto.ReassignPullRequestRequest) (*dto.ReassignPullRequestResponse, error) {
+func (s *Service) ReassignReviewer(ctx context.Context, req dto.ReassignPullRequestRequest) (_ *dto.ReassignPullRequestResponse, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.ReassignReviewer")
+	defer func() { tracing.End(span, err) }()
+
 	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
 		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
 	}
@@ -18,76 +25,65 @@ func (s *Service) ReassignReviewer(ctx context.Context, req dto.ReassignPullRequ
 		return nil, fmt.Errorf("invalid old_user_id: %w", err)
 	}
 
-	logger.Log.Info("Переназначение ревьювера",
+	logger.FromContext(ctx).Info("Переназначение ревьювера",
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("old_user_id", req.OldUserID),
 	)
 
-	pr, err := s.prRepo.GetPR(ctx, req.PullRequestID)
-	if err != nil {
-		logger.Log.Error("PR не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
-		return nil, ErrPRNotFound
-	}
-
-	if pr.Status == dto.StatusMerged {
-		logger.Log.Warn("Попытка переназначить ревьювера на смердженный PR",
-			zap.String("pr_id", req.PullRequestID),
-		)
-		return nil, ErrPRMerged
-	}
+	var (
+		newReviewer string
+		updatedPR   *dto.PullRequestDTO
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		pr, oldReviewer, settings, err := s.lockAssignment(ctx, req.PullRequestID, req.OldUserID, ActionReassign)
+		if err != nil {
+			return err
+		}
 
-	isAssigned, err := s.prRepo.IsReviewerAssigned(ctx, req.PullRequestID, req.OldUserID)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при проверке назначения: %w", err)
-	}
-	if !isAssigned {
-		logger.Log.Warn("Пользователь не назначен ревьювером на этот PR",
-			zap.String("pr_id", req.PullRequestID),
-			zap.String("user_id", req.OldUserID),
-		)
-		return nil, ErrNotAssigned
-	}
+		newReviewer, err = s.findReplacementCandidate(ctx, *settings, oldReviewer.TeamName, pr, req.OldUserID)
+		if err != nil {
+			logger.FromContext(ctx).Warn("Не найден кандидат для замены",
+				zap.String("team_name", oldReviewer.TeamName),
+				zap.Error(err),
+			)
+			return err
+		}
 
-	oldReviewer, err := s.userRepo.GetUser(ctx, req.OldUserID)
-	if err != nil {
-		logger.Log.Error("Старый ревьювер не найден", zap.String("user_id", req.OldUserID), zap.Error(err))
-		return nil, fmt.Errorf("old reviewer not found: %w", err)
-	}
+		removed, err := s.prRepo.RemoveReviewer(ctx, req.PullRequestID, req.OldUserID)
+		if err != nil {
+			logger.FromContext(ctx).Error("Ошибка при удалении старого ревьювера", zap.Error(err))
+			return fmt.Errorf("ошибка при удалении ревьювера: %w", err)
+		}
+		if !removed {
+			return ErrNotAssigned
+		}
 
-	if oldReviewer.TeamName == "" {
-		return nil, fmt.Errorf("old reviewer has no team")
-	}
+		if err := s.prRepo.AddReviewer(ctx, req.PullRequestID, newReviewer); err != nil {
+			logger.FromContext(ctx).Error("Ошибка при добавлении нового ревьювера", zap.Error(err))
+			return fmt.Errorf("ошибка при добавлении ревьювера: %w", err)
+		}
 
-	newReviewer, err := s.findReplacementCandidate(ctx, oldReviewer.TeamName, pr.AuthorID, pr.AssignedReviewers, req.OldUserID)
+		updatedPR, err = s.prRepo.GetPR(ctx, req.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении обновленного PR: %w", err)
+		}
+		return s.updateStaffing(ctx, updatedPR, *settings)
+	})
 	if err != nil {
-		logger.Log.Warn("Не найден кандидат для замены",
-			zap.String("team_name", oldReviewer.TeamName),
-			zap.Error(err),
-		)
+		if errors.Is(err, ErrNoCandidate) {
+			metrics.NoCandidate(metrics.OperationReassign, 1)
+		}
 		return nil, err
 	}
 
-	if err := s.prRepo.RemoveReviewer(ctx, req.PullRequestID, req.OldUserID); err != nil {
-		logger.Log.Error("Ошибка при удалении старого ревьювера", zap.Error(err))
-		return nil, fmt.Errorf("ошибка при удалении ревьювера: %w", err)
-	}
+	metrics.ReviewersReassigned(metrics.ReassignManual, 1)
 
-	if err := s.prRepo.AddReviewer(ctx, req.PullRequestID, newReviewer); err != nil {
-		logger.Log.Error("Ошибка при добавлении нового ревьювера", zap.Error(err))
-		return nil, fmt.Errorf("ошибка при добавлении ревьювера: %w", err)
-	}
-
-	logger.Log.Info("Ревьювер успешно переназначен",
+	logger.FromContext(ctx).Info("Ревьювер успешно переназначен",
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("old_reviewer", req.OldUserID),
 		zap.String("new_reviewer", newReviewer),
 	)
 
-	updatedPR, err := s.prRepo.GetPR(ctx, req.PullRequestID)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при получении обновленного PR: %w", err)
-	}
-
 	return &dto.ReassignPullRequestResponse{
 		PR: dto.PullRequestDTO{
 			PullRequestID:     updatedPR.PullRequestID,
@@ -95,21 +91,113 @@ func (s *Service) ReassignReviewer(ctx context.Context, req dto.ReassignPullRequ
 			AuthorID:          updatedPR.AuthorID,
 			Status:            updatedPR.Status,
 			AssignedReviewers: updatedPR.AssignedReviewers,
+			Understaffed:      updatedPR.Understaffed,
 		},
 		ReplacedBy: newReviewer,
 	}, nil
 }
 
-func (s *Service) findReplacementCandidate(ctx context.Context, teamName, authorID string, currentReviewers []string, oldReviewerID string) (string, error) {
+// lockAssignment блокирует команду автора PR - тот же ключ, что у openPR и
+// changeReviewer, - и под блокировкой перечитывает PR: статус и состав
+// ревьюверов, прочитанные до блокировки, могли измениться. Возвращает PR,
+// назначенного на него ревьювера и настройки, по которым ищется замена
+func (s *Service) lockAssignment(ctx context.Context, prID, reviewerID, action string) (*dto.PullRequestDTO, *dto.UserDTO, *dto.TeamSettingsDTO, error) {
+	check := func(pr *dto.PullRequestDTO) error {
+		if _, err := nextStatus(pr, action); err != nil {
+			logger.FromContext(ctx).Warn("Изменение ревьювера недопустимо в текущем статусе PR",
+				zap.String("pr_id", prID),
+				zap.String("status", pr.Status),
+				zap.String("action", action),
+			)
+			return err
+		}
+		if !slices.Contains(pr.AssignedReviewers, reviewerID) {
+			logger.FromContext(ctx).Warn("Пользователь не назначен ревьювером на этот PR",
+				zap.String("pr_id", prID),
+				zap.String("user_id", reviewerID),
+			)
+			return ErrNotAssigned
+		}
+		return nil
+	}
+
+	pr, err := s.prRepo.GetPR(ctx, prID)
+	if err != nil {
+		logger.FromContext(ctx).Error("PR не найден", zap.String("pr_id", prID), zap.Error(err))
+		return nil, nil, nil, ErrPRNotFound
+	}
+	if err := check(pr); err != nil {
+		return nil, nil, nil, err
+	}
+
+	reviewer, err := s.userRepo.GetUser(ctx, reviewerID)
+	if err != nil {
+		logger.FromContext(ctx).Error("Ревьювер не найден", zap.String("user_id", reviewerID), zap.Error(err))
+		return nil, nil, nil, fmt.Errorf("reviewer not found: %w", err)
+	}
+	if reviewer.TeamName == "" {
+		return nil, nil, nil, fmt.Errorf("reviewer has no team")
+	}
+
+	settings, err := s.settingsForPR(ctx, pr.AuthorID, reviewer.TeamName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := s.teamRepo.LockTeam(ctx, settings.TeamName); err != nil {
+		return nil, nil, nil, err
+	}
+
+	pr, err = s.prRepo.GetPR(ctx, prID)
+	if err != nil {
+		return nil, nil, nil, ErrPRNotFound
+	}
+	if err := check(pr); err != nil {
+		return nil, nil, nil, err
+	}
+	return pr, reviewer, settings, nil
+}
+
+// settingsForPR возвращает настройки команды автора PR, а если автор без
+// команды - настройки команды заменяемого ревьювера
+func (s *Service) settingsForPR(ctx context.Context, authorID, fallbackTeam string) (*dto.TeamSettingsDTO, error) {
+	teamName := fallbackTeam
+	author, err := s.userRepo.GetUser(ctx, authorID)
+	if err == nil && author.TeamName != "" {
+		teamName = author.TeamName
+	}
+
+	settings, err := s.teamRepo.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении настроек команды: %w", err)
+	}
+	return settings, nil
+}
+
+// findReplacementCandidate подбирает замену ревьюверу PR. Автор, текущие
+// ревьюверы, отказавшиеся от ревью этого PR и исчерпавшие лимит открытых
+// ревью не рассматриваются
+func (s *Service) findReplacementCandidate(ctx context.Context, settings dto.TeamSettingsDTO, teamName string, pr *dto.PullRequestDTO, oldReviewerID string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.findReplacementCandidate")
+	defer func() { tracing.End(span, err) }()
+
 	team, err := s.userRepo.GetTeamByName(ctx, teamName)
 	if err != nil {
 		return "", fmt.Errorf("ошибка при получении команды: %w", err)
 	}
 
+	declined, err := s.prRepo.GetDeclinedReviewers(ctx, pr.PullRequestID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при получении отказов от ревью: %w", err)
+	}
+
 	excludeMap := make(map[string]bool)
-	excludeMap[authorID] = true
+	excludeMap[pr.AuthorID] = true
 	excludeMap[oldReviewerID] = true
-	for _, reviewerID := range currentReviewers {
+	for _, reviewerID := range pr.AssignedReviewers {
+		excludeMap[reviewerID] = true
+	}
+	for _, reviewerID := range declined {
 		excludeMap[reviewerID] = true
 	}
 
@@ -120,10 +208,35 @@ func (s *Service) findReplacementCandidate(ctx context.Context, teamName, author
 		}
 	}
 
+	candidates, _, err = s.withinCapacity(ctx, candidates)
+	if err != nil {
+		return "", err
+	}
+
+	if len(candidates) == 0 && settings.AllowCrossTeamFallback {
+		exclude := make([]string, 0, len(excludeMap))
+		for userID := range excludeMap {
+			exclude = append(exclude, userID)
+		}
+		outsiders, _, err := s.pickFromOtherTeams(ctx, settings, 1, exclude)
+		if err != nil {
+			return "", err
+		}
+		if len(outsiders) > 0 {
+			return outsiders[0], nil
+		}
+	}
+
 	if len(candidates) == 0 {
 		return "", ErrNoCandidate
 	}
 
-	selected := selectRandomReviewers(candidates, 1)
+	selected, err := s.pickReviewers(ctx, settings, candidates, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(selected) == 0 {
+		return "", ErrNoCandidate
+	}
 	return selected[0], nil
 }
@@ -0,0 +1,112 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReassignReviewer(t *testing.T) {
+	tests := []struct {
+		name           string
+		members        []dto.UserDTO
+		oldReviewer    string
+		wantErr        error
+		wantReplacedBy string
+		wantReviewers  []string
+	}{
+		{
+			name:           "replacement found",
+			members:        []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4")},
+			oldReviewer:    "u2",
+			wantReplacedBy: "u4",
+			wantReviewers:  []string{"u3", "u4"},
+		},
+		{
+			name:          "no candidate rolls back",
+			members:       []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), {UserID: "u4"}},
+			oldReviewer:   "u2",
+			wantErr:       ErrNoCandidate,
+			wantReviewers: []string{"u2", "u3"},
+		},
+		{
+			name:          "not assigned",
+			members:       []dto.UserDTO{activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4")},
+			oldReviewer:   "u4",
+			wantErr:       ErrNotAssigned,
+			wantReviewers: []string{"u2", "u3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo()
+			repo.addTeam(backendSettings(), tt.members...)
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            dto.StatusOpen,
+				AssignedReviewers: []string{"u2", "u3"},
+			})
+
+			svc, tx := newTestService(t, repo, 0)
+			resp, err := svc.ReassignReviewer(context.Background(), dto.ReassignPullRequestRequest{
+				PullRequestID: "pr-1",
+				OldUserID:     tt.oldReviewer,
+			})
+
+			if got := repo.prs["pr-1"].AssignedReviewers; !slices.Equal(got, tt.wantReviewers) {
+				t.Errorf("reviewers %v, want %v", got, tt.wantReviewers)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if tx.Committed != 0 || tx.RolledBack != 1 {
+					t.Errorf("committed %d, rolled back %d; want 0 and 1", tx.Committed, tx.RolledBack)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.ReplacedBy != tt.wantReplacedBy {
+				t.Errorf("replaced by %q, want %q", resp.ReplacedBy, tt.wantReplacedBy)
+			}
+			if tx.Committed != 1 || tx.RolledBack != 0 {
+				t.Errorf("committed %d, rolled back %d; want 1 and 0", tx.Committed, tx.RolledBack)
+			}
+		})
+	}
+}
+
+func TestReassignReviewerLocksAuthorTeam(t *testing.T) {
+	repo := newMemRepo()
+	settings := backendSettings()
+	settings.AllowCrossTeamFallback = true
+	repo.addTeam(settings, activeUser("u1"), activeUser("u2"))
+	repo.addTeam(dto.TeamSettingsDTO{TeamName: "frontend"}, activeUser("f1"), activeUser("f2"))
+	repo.addPR(dto.PullRequestDTO{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            dto.StatusOpen,
+		AssignedReviewers: []string{"u2", "f1"},
+	})
+
+	svc, _ := newTestService(t, repo, 0)
+	resp, err := svc.ReassignReviewer(context.Background(), dto.ReassignPullRequestRequest{
+		PullRequestID: "pr-1",
+		OldUserID:     "f1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ReplacedBy != "f2" {
+		t.Errorf("replaced by %q, want f2", resp.ReplacedBy)
+	}
+	if want := []string{"backend"}; !slices.Equal(repo.locks, want) {
+		t.Errorf("locked %v, want %v", repo.locks, want)
+	}
+}
@@ -0,0 +1,151 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
+	"AvitoTech/pkg/logger"
+	"AvitoTech/pkg/validator"
+	"context"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// SubmitReview сохраняет вердикт назначенного ревьювера. Повторный вердикт
+// заменяет предыдущий, но COMMENTED не отменяет APPROVED и CHANGES_REQUESTED
+func (s *Service) SubmitReview(ctx context.Context, req dto.ReviewPullRequestRequest) (_ *dto.ReviewPullRequestResponse, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.SubmitReview")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
+		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
+	}
+	if err := validator.ValidateUserID(req.ReviewerID); err != nil {
+		return nil, fmt.Errorf("invalid reviewer_id: %w", err)
+	}
+	if !slices.Contains(dto.Verdicts, req.Verdict) {
+		return nil, ErrInvalidVerdict
+	}
+
+	logger.FromContext(ctx).Info("Ревью PR",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("reviewer_id", req.ReviewerID),
+		zap.String("verdict", req.Verdict),
+	)
+
+	var (
+		pr      *dto.PullRequestDTO
+		reviews []dto.ReviewDTO
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		pr, err = s.prRepo.GetPR(ctx, req.PullRequestID)
+		if err != nil {
+			logger.FromContext(ctx).Error("PR не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
+			return ErrPRNotFound
+		}
+
+		// вердикт не должен измениться между проверкой одобрений в MergePR
+		// и мерджем
+		if err := s.lockAuthorTeam(ctx, pr); err != nil {
+			return err
+		}
+		pr, err = s.prRepo.GetPR(ctx, req.PullRequestID)
+		if err != nil {
+			return ErrPRNotFound
+		}
+
+		if _, err := nextStatus(pr, ActionReview); err != nil {
+			logger.FromContext(ctx).Warn("Ревью недопустимо в текущем статусе PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("status", pr.Status),
+			)
+			return err
+		}
+
+		updated, err := s.prRepo.SetReviewVerdict(ctx, req.PullRequestID, req.ReviewerID, req.Verdict)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении вердикта: %w", err)
+		}
+		if !updated {
+			logger.FromContext(ctx).Warn("Пользователь не назначен ревьювером на этот PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("user_id", req.ReviewerID),
+			)
+			return ErrNotAssigned
+		}
+
+		reviews, err = s.prRepo.GetReviews(ctx, req.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении вердиктов: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ReviewSubmitted(req.Verdict)
+
+	logger.FromContext(ctx).Info("Вердикт ревьювера сохранён",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("reviewer_id", req.ReviewerID),
+		zap.String("verdict", req.Verdict),
+	)
+
+	return &dto.ReviewPullRequestResponse{PR: *pr, Reviews: reviews}, nil
+}
+
+// lockAuthorTeam берёт блокировку команды автора PR - тот же ключ, что у
+// openPR и изменений состава ревьюверов. У автора без команды блокировать
+// нечего
+func (s *Service) lockAuthorTeam(ctx context.Context, pr *dto.PullRequestDTO) error {
+	author, err := s.userRepo.GetUser(ctx, pr.AuthorID)
+	if err != nil || author.TeamName == "" {
+		return nil
+	}
+	return s.teamRepo.LockTeam(ctx, author.TeamName)
+}
+
+// checkApproved требует не меньше required_approvals одобрений команды автора
+// и ни одного CHANGES_REQUESTED среди текущих ревьюверов
+func (s *Service) checkApproved(ctx context.Context, pr *dto.PullRequestDTO) error {
+	required := dto.DefaultRequiredApprovals
+	author, err := s.userRepo.GetUser(ctx, pr.AuthorID)
+	if err == nil && author.TeamName != "" {
+		settings, err := s.teamRepo.GetTeamSettings(ctx, author.TeamName)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении настроек команды: %w", err)
+		}
+		required = settings.RequiredApprovals
+	}
+
+	reviews, err := s.prRepo.GetReviews(ctx, pr.PullRequestID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении вердиктов: %w", err)
+	}
+
+	approvals, changesRequested := 0, 0
+	for _, review := range reviews {
+		switch review.Verdict {
+		case dto.VerdictApproved:
+			approvals++
+		case dto.VerdictChangesRequested:
+			changesRequested++
+		}
+	}
+
+	if changesRequested > 0 || approvals < required {
+		logger.FromContext(ctx).Warn("PR не одобрен",
+			zap.String("pr_id", pr.PullRequestID),
+			zap.Int("approvals", approvals),
+			zap.Int("required_approvals", required),
+			zap.Int("changes_requested", changesRequested),
+		)
+		return fmt.Errorf("%w: %d of %d required approvals, %d change requests",
+			ErrNotApproved, approvals, required, changesRequested)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
+	"AvitoTech/pkg/logger"
+	"AvitoTech/pkg/validator"
+	"context"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// AddReviewer явно назначает ревьювера на открытый PR. Ревьювер должен быть
+// активен, не быть автором, ещё не быть назначен и не отказываться от этого
+// PR, состоять в команде автора (если команда не разрешает ревьюверов из
+// других команд) и не исчерпать лимит открытых ревью, а общее число
+// ревьюверов не должно превысить max_reviewers команды автора
+func (s *Service) AddReviewer(ctx context.Context, req dto.ReviewerChangeRequest) (_ *dto.PullRequestDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.AddReviewer")
+	defer func() { tracing.End(span, err) }()
+
+	return s.changeReviewer(ctx, req, ActionAddReviewer, func(ctx context.Context, pr *dto.PullRequestDTO, settings dto.TeamSettingsDTO) error {
+		if req.ReviewerID == pr.AuthorID {
+			return ErrReviewerIsAuthor
+		}
+		if slices.Contains(pr.AssignedReviewers, req.ReviewerID) {
+			return ErrAlreadyAssigned
+		}
+
+		reviewer, err := s.userRepo.GetUser(ctx, req.ReviewerID)
+		if err != nil {
+			logger.FromContext(ctx).Warn("Ревьювер не найден", zap.String("user_id", req.ReviewerID), zap.Error(err))
+			return ErrReviewerNotFound
+		}
+		if !reviewer.IsActive {
+			return ErrReviewerInactive
+		}
+		if reviewer.TeamName != settings.TeamName && !settings.AllowCrossTeamFallback {
+			return ErrReviewerOtherTeam
+		}
+
+		if len(pr.AssignedReviewers) >= settings.MaxReviewers {
+			return fmt.Errorf("%w: %d of %d", ErrTooManyReviewers, len(pr.AssignedReviewers), settings.MaxReviewers)
+		}
+
+		declined, err := s.prRepo.GetDeclinedReviewers(ctx, pr.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении отказавшихся ревьюверов: %w", err)
+		}
+		if slices.Contains(declined, req.ReviewerID) {
+			return ErrReviewerDeclined
+		}
+
+		available, _, err := s.withinCapacity(ctx, []string{req.ReviewerID})
+		if err != nil {
+			return err
+		}
+		if len(available) == 0 {
+			return ErrReviewerAtCapacity
+		}
+
+		if err := s.prRepo.AddReviewer(ctx, pr.PullRequestID, req.ReviewerID); err != nil {
+			logger.FromContext(ctx).Error("Ошибка при добавлении ревьювера", zap.Error(err))
+			return fmt.Errorf("ошибка при добавлении ревьювера: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemoveReviewer снимает ревьювера с открытого PR без замены. Ревьюверов не
+// может остаться меньше min_reviewers команды автора
+func (s *Service) RemoveReviewer(ctx context.Context, req dto.ReviewerChangeRequest) (_ *dto.PullRequestDTO, err error) {
+	ctx, span := tracing.Start(ctx, "pr.Service.RemoveReviewer")
+	defer func() { tracing.End(span, err) }()
+
+	return s.changeReviewer(ctx, req, ActionRemoveReviewer, func(ctx context.Context, pr *dto.PullRequestDTO, settings dto.TeamSettingsDTO) error {
+		if !slices.Contains(pr.AssignedReviewers, req.ReviewerID) {
+			return ErrNotAssigned
+		}
+		if len(pr.AssignedReviewers)-1 < settings.MinReviewers {
+			return ErrNotEnoughReviewers
+		}
+
+		removed, err := s.prRepo.RemoveReviewer(ctx, pr.PullRequestID, req.ReviewerID)
+		if err != nil {
+			logger.FromContext(ctx).Error("Ошибка при удалении ревьювера", zap.Error(err))
+			return fmt.Errorf("ошибка при удалении ревьювера: %w", err)
+		}
+		if !removed {
+			return ErrNotAssigned
+		}
+		return nil
+	})
+}
+
+// changeReviewer проверяет статус PR и под блокировкой команды автора
+// применяет изменение состава ревьюверов
+func (s *Service) changeReviewer(
+	ctx context.Context,
+	req dto.ReviewerChangeRequest,
+	action string,
+	apply func(ctx context.Context, pr *dto.PullRequestDTO, settings dto.TeamSettingsDTO) error,
+) (*dto.PullRequestDTO, error) {
+	if err := validator.ValidateUserID(req.PullRequestID); err != nil {
+		return nil, fmt.Errorf("invalid pull_request_id: %w", err)
+	}
+	if err := validator.ValidateUserID(req.ReviewerID); err != nil {
+		return nil, fmt.Errorf("invalid reviewer_id: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("Изменение состава ревьюверов",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("reviewer_id", req.ReviewerID),
+		zap.String("action", action),
+	)
+
+	pr, err := s.prRepo.GetPR(ctx, req.PullRequestID)
+	if err != nil {
+		logger.FromContext(ctx).Error("PR не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
+		return nil, ErrPRNotFound
+	}
+
+	settings, err := s.authorSettings(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedPR *dto.PullRequestDTO
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.LockTeam(ctx, settings.TeamName); err != nil {
+			return err
+		}
+
+		// состав перечитывается под блокировкой команды
+		pr, err := s.prRepo.GetPR(ctx, req.PullRequestID)
+		if err != nil {
+			return ErrPRNotFound
+		}
+		if _, err := nextStatus(pr, action); err != nil {
+			return err
+		}
+
+		if err := apply(ctx, pr, *settings); err != nil {
+			logger.FromContext(ctx).Warn("Изменение состава ревьюверов отклонено",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+				zap.Error(err),
+			)
+			return err
+		}
+
+		updatedPR, err = s.prRepo.GetPR(ctx, req.PullRequestID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении обновленного PR: %w", err)
+		}
+		return s.updateStaffing(ctx, updatedPR, *settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ReviewerChanged(action)
+
+	logger.FromContext(ctx).Info("Состав ревьюверов изменён",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("action", action),
+		zap.Strings("reviewers", updatedPR.AssignedReviewers),
+	)
+
+	return updatedPR, nil
+}
@@ -0,0 +1,80 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestAddReviewer(t *testing.T) {
+	tests := []struct {
+		name      string
+		reviewer  string
+		crossTeam bool
+		declined  []string
+		limit     int
+		assigned  []string
+		wantErr   error
+	}{
+		{name: "teammate", reviewer: "u4"},
+		{name: "author", reviewer: "u1", wantErr: ErrReviewerIsAuthor},
+		{name: "already assigned", reviewer: "u2", wantErr: ErrAlreadyAssigned},
+		{name: "unknown user", reviewer: "u9", wantErr: ErrReviewerNotFound},
+		{name: "inactive", reviewer: "u5", wantErr: ErrReviewerInactive},
+		{name: "other team", reviewer: "f1", wantErr: ErrReviewerOtherTeam},
+		{name: "other team with fallback", reviewer: "f1", crossTeam: true},
+		{name: "declined", reviewer: "u4", declined: []string{"u4"}, wantErr: ErrReviewerDeclined},
+		{name: "at capacity", reviewer: "u4", limit: 1, wantErr: ErrReviewerAtCapacity},
+		{name: "max reviewers", reviewer: "u4", assigned: []string{"u2", "u3", "u6"}, wantErr: ErrTooManyReviewers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemRepo()
+			settings := backendSettings()
+			settings.AllowCrossTeamFallback = tt.crossTeam
+			repo.addTeam(settings,
+				activeUser("u1"), activeUser("u2"), activeUser("u3"), activeUser("u4"),
+				dto.UserDTO{UserID: "u5"}, activeUser("u6"),
+			)
+			repo.addTeam(dto.TeamSettingsDTO{TeamName: "frontend"}, activeUser("f1"))
+			repo.loads["u4"] = 1
+
+			assigned := tt.assigned
+			if assigned == nil {
+				assigned = []string{"u2", "u3"}
+			}
+			repo.addPR(dto.PullRequestDTO{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            dto.StatusOpen,
+				AssignedReviewers: assigned,
+			})
+			repo.declines["pr-1"] = tt.declined
+
+			svc, tx := newTestService(t, repo, tt.limit)
+			pr, err := svc.AddReviewer(context.Background(), dto.ReviewerChangeRequest{
+				PullRequestID: "pr-1",
+				ReviewerID:    tt.reviewer,
+			})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if tx.RolledBack != 1 {
+					t.Errorf("rolled back %d transactions, want 1", tx.RolledBack)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Contains(pr.AssignedReviewers, tt.reviewer) {
+				t.Errorf("reviewers %v do not contain %s", pr.AssignedReviewers, tt.reviewer)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+type ReviewerSelector interface {
+	Select(ctx context.Context, teamName string, candidates []string, count int) []string
+}
+
+// LoadBasedSelector - стратегия, которой для выбора нужно текущее число
+// открытых ревью у кандидатов. Сервис сам получает нагрузку из репозитория
+type LoadBasedSelector interface {
+	ReviewerSelector
+	SelectByLoad(candidates []string, loads map[string]int, count int) []string
+}
+
+type SelectorConfig struct {
+	DefaultStrategy string
+	TeamStrategies  map[string]string
+	Weights         map[string]int
+	// MaxOpenReviews - лимит открытых ревью на пользователя по умолчанию, 0 - без лимита
+	MaxOpenReviews int
+}
+
+func NewSelector(strategy string, weights map[string]int) (ReviewerSelector, error) {
+	switch strategy {
+	case dto.StrategyRandom:
+		return NewRandomSelector(), nil
+	case dto.StrategyRoundRobin:
+		return NewRoundRobinSelector(), nil
+	case dto.StrategyWeighted:
+		return NewWeightedSelector(weights), nil
+	case dto.StrategyLoadAware, dto.StrategyLeastLoaded:
+		return NewLoadAwareSelector(), nil
+	default:
+		return nil, fmt.Errorf("unknown reviewer strategy: %s", strategy)
+	}
+}
+
+type RandomSelector struct{}
+
+func NewRandomSelector() *RandomSelector {
+	return &RandomSelector{}
+}
+
+func (s *RandomSelector) Select(_ context.Context, _ string, candidates []string, count int) []string {
+	if count <= 0 || len(candidates) == 0 {
+		return []string{}
+	}
+	return selectRandomReviewers(candidates, count)
+}
+
+// RoundRobinSelector обходит участников команды по кругу, начиная с места,
+// где остановился предыдущий выбор
+type RoundRobinSelector struct {
+	mu      sync.Mutex
+	cursors map[string]int
+}
+
+func NewRoundRobinSelector() *RoundRobinSelector {
+	return &RoundRobinSelector{cursors: make(map[string]int)}
+}
+
+func (s *RoundRobinSelector) Select(_ context.Context, teamName string, candidates []string, count int) []string {
+	if count <= 0 || len(candidates) == 0 {
+		return []string{}
+	}
+
+	ordered := make([]string, len(candidates))
+	copy(ordered, candidates)
+	sort.Strings(ordered)
+
+	if count > len(ordered) {
+		count = len(ordered)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := s.cursors[teamName] % len(ordered)
+	selected := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		selected = append(selected, ordered[(start+i)%len(ordered)])
+	}
+	s.cursors[teamName] = (start + count) % len(ordered)
+
+	return selected
+}
+
+// WeightedSelector выбирает случайно пропорционально весам участников.
+// Участники без явного веса получают вес 1, вес 0 исключает из выбора
+type WeightedSelector struct {
+	weights map[string]int
+}
+
+func NewWeightedSelector(weights map[string]int) *WeightedSelector {
+	w := make(map[string]int, len(weights))
+	for userID, weight := range weights {
+		w[userID] = weight
+	}
+	return &WeightedSelector{weights: w}
+}
+
+func (s *WeightedSelector) Select(_ context.Context, _ string, candidates []string, count int) []string {
+	if count <= 0 || len(candidates) == 0 {
+		return []string{}
+	}
+
+	type keyed struct {
+		userID string
+		key    float64
+	}
+
+	// Взвешенная выборка без возвращения (Efraimidis–Spirakis)
+	keys := make([]keyed, 0, len(candidates))
+	for _, userID := range candidates {
+		weight, ok := s.weights[userID]
+		if !ok {
+			weight = 1
+		}
+		if weight <= 0 {
+			continue
+		}
+		keys = append(keys, keyed{
+			userID: userID,
+			key:    math.Pow(rand.Float64(), 1/float64(weight)),
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].key > keys[j].key
+	})
+
+	if count > len(keys) {
+		count = len(keys)
+	}
+	selected := make([]string, 0, count)
+	for _, k := range keys[:count] {
+		selected = append(selected, k.userID)
+	}
+
+	return selected
+}
+
+// LoadAwareSelector выбирает кандидатов с наименьшим числом открытых PR
+// на ревью. Равные значения разрешаются случайно
+type LoadAwareSelector struct{}
+
+func NewLoadAwareSelector() *LoadAwareSelector {
+	return &LoadAwareSelector{}
+}
+
+func (s *LoadAwareSelector) Select(_ context.Context, _ string, candidates []string, count int) []string {
+	return s.SelectByLoad(candidates, nil, count)
+}
+
+func (s *LoadAwareSelector) SelectByLoad(candidates []string, loads map[string]int, count int) []string {
+	if count <= 0 || len(candidates) == 0 {
+		return []string{}
+	}
+
+	ordered := shuffled(candidates)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return loads[ordered[i]] < loads[ordered[j]]
+	})
+
+	if count > len(ordered) {
+		count = len(ordered)
+	}
+	return ordered[:count]
+}
+
+func shuffled(candidates []string) []string {
+	result := make([]string, len(candidates))
+	copy(result, candidates)
+	rand.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
+}
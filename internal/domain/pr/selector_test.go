@@ -0,0 +1,175 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestNewSelector(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     any
+		wantErr  bool
+	}{
+		{strategy: dto.StrategyRandom, want: &RandomSelector{}},
+		{strategy: dto.StrategyRoundRobin, want: &RoundRobinSelector{}},
+		{strategy: dto.StrategyWeighted, want: &WeightedSelector{}},
+		{strategy: dto.StrategyLoadAware, want: &LoadAwareSelector{}},
+		{strategy: dto.StrategyLeastLoaded, want: &LoadAwareSelector{}},
+		{strategy: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			sel, err := NewSelector(tt.strategy, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewSelector(%q): expected error", tt.strategy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSelector(%q): %v", tt.strategy, err)
+			}
+			if got, want := typeName(sel), typeName(tt.want); got != want {
+				t.Errorf("NewSelector(%q) = %s, want %s", tt.strategy, got, want)
+			}
+		})
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case *RandomSelector:
+		return "random"
+	case *RoundRobinSelector:
+		return "round-robin"
+	case *WeightedSelector:
+		return "weighted"
+	case *LoadAwareSelector:
+		return "load-aware"
+	default:
+		return "unknown"
+	}
+}
+
+func TestSelectorsCount(t *testing.T) {
+	candidates := []string{"u1", "u2", "u3"}
+	selectors := map[string]ReviewerSelector{
+		"random":      NewRandomSelector(),
+		"round-robin": NewRoundRobinSelector(),
+		"weighted":    NewWeightedSelector(nil),
+		"load-aware":  NewLoadAwareSelector(),
+	}
+	tests := []struct {
+		name       string
+		candidates []string
+		count      int
+		want       int
+	}{
+		{name: "zero count", candidates: candidates, count: 0, want: 0},
+		{name: "negative count", candidates: candidates, count: -1, want: 0},
+		{name: "no candidates", candidates: nil, count: 2, want: 0},
+		{name: "fewer than candidates", candidates: candidates, count: 2, want: 2},
+		{name: "more than candidates", candidates: candidates, count: 5, want: 3},
+	}
+
+	for selName, sel := range selectors {
+		for _, tt := range tests {
+			t.Run(selName+"/"+tt.name, func(t *testing.T) {
+				got := sel.Select(context.Background(), "backend", tt.candidates, tt.count)
+				if len(got) != tt.want {
+					t.Fatalf("got %d reviewers %v, want %d", len(got), got, tt.want)
+				}
+				seen := make(map[string]bool, len(got))
+				for _, userID := range got {
+					if !slices.Contains(tt.candidates, userID) {
+						t.Errorf("selected %s is not a candidate", userID)
+					}
+					if seen[userID] {
+						t.Errorf("selected %s twice", userID)
+					}
+					seen[userID] = true
+				}
+			})
+		}
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	sel := NewRoundRobinSelector()
+	candidates := []string{"u3", "u1", "u2"}
+
+	tests := []struct {
+		team  string
+		count int
+		want  []string
+	}{
+		{team: "backend", count: 2, want: []string{"u1", "u2"}},
+		{team: "backend", count: 2, want: []string{"u3", "u1"}},
+		{team: "frontend", count: 1, want: []string{"u1"}},
+		{team: "backend", count: 1, want: []string{"u2"}},
+		{team: "backend", count: 5, want: []string{"u3", "u1", "u2"}},
+	}
+
+	for i, tt := range tests {
+		got := sel.Select(context.Background(), tt.team, candidates, tt.count)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("call %d (%s, %d) = %v, want %v", i, tt.team, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestWeightedSelectorExcludesZeroWeight(t *testing.T) {
+	sel := NewWeightedSelector(map[string]int{"u1": 0, "u2": 3})
+
+	for range 50 {
+		got := sel.Select(context.Background(), "backend", []string{"u1", "u2", "u3"}, 3)
+		if slices.Contains(got, "u1") {
+			t.Fatalf("user with weight 0 selected: %v", got)
+		}
+		if len(got) != 2 {
+			t.Fatalf("got %v, want u2 and u3", got)
+		}
+	}
+}
+
+func TestLoadAwareSelector(t *testing.T) {
+	tests := []struct {
+		name  string
+		loads map[string]int
+		count int
+		want  []string
+	}{
+		{
+			name:  "least loaded first",
+			loads: map[string]int{"u1": 3, "u2": 0, "u3": 1},
+			count: 2,
+			want:  []string{"u2", "u3"},
+		},
+		{
+			name:  "missing load counts as zero",
+			loads: map[string]int{"u1": 2, "u2": 1},
+			count: 1,
+			want:  []string{"u3"},
+		},
+		{
+			name:  "all candidates",
+			loads: map[string]int{"u1": 2, "u2": 1, "u3": 0},
+			count: 3,
+			want:  []string{"u3", "u2", "u1"},
+		},
+	}
+
+	sel := NewLoadAwareSelector()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sel.SelectByLoad([]string{"u1", "u2", "u3"}, tt.loads, tt.count)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
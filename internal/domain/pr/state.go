@@ -0,0 +1,69 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"fmt"
+)
+
+// Действия над PR. Изменение состава ревьюверов, ревью и отказ от него -
+// тоже действия: статус они не меняют, но допустимы только в OPEN
+const (
+	ActionReady    = "ready"
+	ActionClose    = "close"
+	ActionReopen   = "reopen"
+	ActionMerge    = "merge"
+	ActionReassign = "reassign"
+	ActionReview   = "review"
+	ActionDecline  = "decline"
+
+	ActionAddReviewer    = "add_reviewer"
+	ActionRemoveReviewer = "remove_reviewer"
+)
+
+// transitions - конечный автомат PR: статус -> действие -> новый статус.
+// DRAFT - черновик без ревьюверов, CLOSED - отклонённый PR
+var transitions = map[string]map[string]string{
+	dto.StatusDraft: {
+		ActionReady: dto.StatusOpen,
+		ActionClose: dto.StatusClosed,
+	},
+	dto.StatusOpen: {
+		ActionClose:    dto.StatusClosed,
+		ActionMerge:    dto.StatusMerged,
+		ActionReassign: dto.StatusOpen,
+		ActionReview:   dto.StatusOpen,
+		ActionDecline:  dto.StatusOpen,
+
+		ActionAddReviewer:    dto.StatusOpen,
+		ActionRemoveReviewer: dto.StatusOpen,
+	},
+	dto.StatusClosed: {
+		ActionReopen: dto.StatusOpen,
+	},
+	dto.StatusMerged: {},
+}
+
+// TransitionError - действие недопустимо в текущем статусе PR. Для MERGED
+// совпадает также с ErrPRMerged
+type TransitionError struct {
+	PullRequestID string
+	From          string
+	Action        string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot %s pull request %s in status %s", e.Action, e.PullRequestID, e.From)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition || (target == ErrPRMerged && e.From == dto.StatusMerged)
+}
+
+// nextStatus возвращает статус после действия или *TransitionError
+func nextStatus(pr *dto.PullRequestDTO, action string) (string, error) {
+	to, ok := transitions[pr.Status][action]
+	if !ok {
+		return "", &TransitionError{PullRequestID: pr.PullRequestID, From: pr.Status, Action: action}
+	}
+	return to, nil
+}
@@ -0,0 +1,70 @@
+package pr
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"errors"
+	"testing"
+)
+
+func TestNextStatus(t *testing.T) {
+	tests := []struct {
+		from   string
+		action string
+		want   string
+	}{
+		{from: dto.StatusDraft, action: ActionReady, want: dto.StatusOpen},
+		{from: dto.StatusDraft, action: ActionClose, want: dto.StatusClosed},
+		{from: dto.StatusDraft, action: ActionMerge},
+		{from: dto.StatusDraft, action: ActionReassign},
+		{from: dto.StatusDraft, action: ActionReview},
+		{from: dto.StatusDraft, action: ActionReopen},
+
+		{from: dto.StatusOpen, action: ActionClose, want: dto.StatusClosed},
+		{from: dto.StatusOpen, action: ActionMerge, want: dto.StatusMerged},
+		{from: dto.StatusOpen, action: ActionReassign, want: dto.StatusOpen},
+		{from: dto.StatusOpen, action: ActionReview, want: dto.StatusOpen},
+		{from: dto.StatusOpen, action: ActionDecline, want: dto.StatusOpen},
+		{from: dto.StatusOpen, action: ActionAddReviewer, want: dto.StatusOpen},
+		{from: dto.StatusOpen, action: ActionRemoveReviewer, want: dto.StatusOpen},
+		{from: dto.StatusOpen, action: ActionReady},
+		{from: dto.StatusOpen, action: ActionReopen},
+
+		{from: dto.StatusClosed, action: ActionReopen, want: dto.StatusOpen},
+		{from: dto.StatusClosed, action: ActionMerge},
+		{from: dto.StatusClosed, action: ActionClose},
+		{from: dto.StatusClosed, action: ActionDecline},
+
+		{from: dto.StatusMerged, action: ActionReopen},
+		{from: dto.StatusMerged, action: ActionClose},
+		{from: dto.StatusMerged, action: ActionMerge},
+		{from: dto.StatusMerged, action: ActionReassign},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"/"+tt.action, func(t *testing.T) {
+			pr := &dto.PullRequestDTO{PullRequestID: "pr-1", Status: tt.from}
+			got, err := nextStatus(pr, tt.action)
+
+			if tt.want == "" {
+				var transitionErr *TransitionError
+				if !errors.As(err, &transitionErr) {
+					t.Fatalf("expected *TransitionError, got %v", err)
+				}
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Errorf("error does not match ErrInvalidTransition")
+				}
+				if got := errors.Is(err, ErrPRMerged); got != (tt.from == dto.StatusMerged) {
+					t.Errorf("errors.Is(err, ErrPRMerged) = %v for status %s", got, tt.from)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
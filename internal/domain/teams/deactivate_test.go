@@ -0,0 +1,143 @@
+package teams
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/internal/infrastructure/postgres/postgrestest"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// deactivationRepo реализует методы репозиториев и планировщика передачи
+// ревью, которые вызывает DeactivateMembers; вызов остальных паникует на
+// встроенном nil-интерфейсе
+type deactivationRepo struct {
+	interfaces.TeamRepository
+	interfaces.UserRepository
+
+	members []string
+	// calls - вызовы в порядке выполнения, batches - пакеты DeactivateUsers
+	calls   []string
+	batches [][]string
+}
+
+func (r *deactivationRepo) TeamExists(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+func (r *deactivationRepo) LockTeam(context.Context, string) error {
+	r.calls = append(r.calls, "LockTeam")
+	return nil
+}
+
+func (r *deactivationRepo) GetTeamByName(_ context.Context, teamName string) (*dto.TeamDTO, error) {
+	r.calls = append(r.calls, "GetTeamByName")
+	team := &dto.TeamDTO{TeamName: teamName}
+	for _, userID := range r.members {
+		team.Members = append(team.Members, dto.TeamMemberDTO{UserID: userID, IsActive: true})
+	}
+	return team, nil
+}
+
+func (r *deactivationRepo) DeactivateUsers(_ context.Context, userIDs []string, plan dto.HandoffReportDTO) (*dto.HandoffReportDTO, error) {
+	r.calls = append(r.calls, "DeactivateUsers")
+	r.batches = append(r.batches, slices.Clone(userIDs))
+	return &plan, nil
+}
+
+func (r *deactivationRepo) PlanReviewHandoff(context.Context, string, []string) (*dto.HandoffReportDTO, error) {
+	r.calls = append(r.calls, "PlanReviewHandoff")
+	return &dto.HandoffReportDTO{Reassigned: []dto.ReviewHandoffDTO{}, NoCandidate: []dto.ReviewHandoffDTO{}}, nil
+}
+
+func (r *deactivationRepo) UpdateHandoffStaffing(context.Context, string, *dto.HandoffReportDTO) error {
+	r.calls = append(r.calls, "UpdateHandoffStaffing")
+	return nil
+}
+
+func memberIDs(n int) []string {
+	userIDs := make([]string, n)
+	for i := range userIDs {
+		userIDs[i] = fmt.Sprintf("u%d", i+1)
+	}
+	return userIDs
+}
+
+func TestDeactivateMembers(t *testing.T) {
+	tests := []struct {
+		name        string
+		members     []string
+		userIDs     []string
+		wantErr     error
+		wantBatches [][]string
+		wantCalls   []string
+	}{
+		{
+			name:        "whole 200-member team in one batch",
+			members:     memberIDs(maxTeamMembers),
+			wantBatches: [][]string{memberIDs(maxTeamMembers)},
+			wantCalls:   []string{"LockTeam", "GetTeamByName", "PlanReviewHandoff", "DeactivateUsers", "UpdateHandoffStaffing"},
+		},
+		{
+			name:        "listed members",
+			members:     memberIDs(3),
+			userIDs:     []string{"u3", "u1"},
+			wantBatches: [][]string{{"u3", "u1"}},
+			wantCalls:   []string{"LockTeam", "GetTeamByName", "PlanReviewHandoff", "DeactivateUsers", "UpdateHandoffStaffing"},
+		},
+		{
+			name:      "member of another team is checked under the lock",
+			members:   memberIDs(3),
+			userIDs:   []string{"u1", "u9"},
+			wantErr:   ErrInvalidMembers,
+			wantCalls: []string{"LockTeam", "GetTeamByName"},
+		},
+		{
+			name:    "too many user_ids",
+			members: memberIDs(3),
+			userIDs: memberIDs(maxTeamMembers + 1),
+			wantErr: ErrInvalidMembers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &deactivationRepo{members: tt.members}
+			tx := postgrestest.NewFakeTxManager()
+			svc := NewService(repo, repo, nil, tx, repo)
+
+			resp, err := svc.DeactivateMembers(context.Background(), dto.DeactivateTeamRequest{
+				TeamName: "backend",
+				UserIDs:  tt.userIDs,
+			})
+
+			if !slices.Equal(repo.calls, tt.wantCalls) {
+				t.Errorf("calls %v, want %v", repo.calls, tt.wantCalls)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if tx.Committed != 0 {
+					t.Errorf("committed %d transactions after rejected request", tx.Committed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.EqualFunc(repo.batches, tt.wantBatches, slices.Equal) {
+				t.Errorf("batches %v, want %v", repo.batches, tt.wantBatches)
+			}
+			if want := tt.wantBatches[0]; !slices.Equal(resp.Deactivated, want) {
+				t.Errorf("deactivated %v, want %v", resp.Deactivated, want)
+			}
+			if tx.Committed != 1 {
+				t.Errorf("committed %d transactions, want 1", tx.Committed)
+			}
+		})
+	}
+}
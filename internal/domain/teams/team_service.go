@@ -3,10 +3,13 @@ package teams
 import (
 	"AvitoTech/internal/domain/dto"
 	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
 	"AvitoTech/pkg/validator"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 )
 
 const (
@@ -16,18 +19,32 @@ const (
 	TeamNotFound   = "NOT_FOUND"
 )
 
-var ErrTeamExists = errors.New("teams already exists")
+var (
+	ErrTeamExists      = errors.New("teams already exists")
+	ErrTeamNotFound    = errors.New("team not found")
+	ErrInvalidSettings = errors.New("invalid team settings")
+	ErrInvalidMembers  = errors.New("invalid team members")
+	ErrInvalidLeads    = errors.New("invalid team leads")
+)
+
+const maxTeamMembers = 200
 
 type Service struct {
-	teams interfaces.TeamRepository
-	users interfaces.UserRepository
+	teams   interfaces.TeamRepository
+	users   interfaces.UserRepository
+	leads   interfaces.TeamLeadRepository
+	tx      interfaces.TxManager
+	handoff interfaces.ReviewHandoffPlanner
 }
 
-func NewService(t interfaces.TeamRepository, u interfaces.UserRepository) *Service {
-	return &Service{teams: t, users: u}
+func NewService(t interfaces.TeamRepository, u interfaces.UserRepository, l interfaces.TeamLeadRepository, tx interfaces.TxManager, h interfaces.ReviewHandoffPlanner) *Service {
+	return &Service{teams: t, users: u, leads: l, tx: tx, handoff: h}
 }
 
-func (s *Service) CreateTeam(ctx context.Context, req dto.TeamDTO) error {
+func (s *Service) CreateTeam(ctx context.Context, req dto.TeamDTO) (err error) {
+	ctx, span := tracing.Start(ctx, "teams.Service.CreateTeam")
+	defer func() { tracing.End(span, err) }()
+
 	if err := validator.ValidateTeamName(req.TeamName); err != nil {
 		return fmt.Errorf("invalid team_name: %w", err)
 	}
@@ -36,8 +53,8 @@ func (s *Service) CreateTeam(ctx context.Context, req dto.TeamDTO) error {
 		return errors.New("members list cannot be empty")
 	}
 
-	if len(req.Members) > 200 {
-		return errors.New("too many members (max 200)")
+	if len(req.Members) > maxTeamMembers {
+		return fmt.Errorf("too many members (max %d)", maxTeamMembers)
 	}
 
 	userIDs := make([]string, 0, len(req.Members))
@@ -63,7 +80,9 @@ func (s *Service) CreateTeam(ctx context.Context, req dto.TeamDTO) error {
 		return ErrTeamExists
 	}
 
-	err = s.teams.CreateTeam(ctx, req)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.teams.CreateTeam(ctx, req)
+	})
 	if err != nil {
 		return fmt.Errorf("ошибка при создании команды: %v", err)
 	}
@@ -71,6 +90,300 @@ func (s *Service) CreateTeam(ctx context.Context, req dto.TeamDTO) error {
 	return nil
 }
 
-func (s *Service) GetTeam(ctx context.Context, teamName string) (dto.TeamDTO, error) {
+func (s *Service) GetTeam(ctx context.Context, teamName string) (_ dto.TeamDTO, err error) {
+	ctx, span := tracing.Start(ctx, "teams.Service.GetTeam")
+	defer func() { tracing.End(span, err) }()
+
 	return s.teams.GetTeam(ctx, teamName)
 }
+
+func (s *Service) GetSettings(ctx context.Context, teamName string) (_ *dto.TeamSettingsDTO, err error) {
+	ctx, span := tracing.Start(ctx, "teams.Service.GetSettings")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateTeamName(teamName); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+
+	exists, err := s.teams.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке существования команды: %v", err)
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	return s.teams.GetTeamSettings(ctx, teamName)
+}
+
+// UpdateSettings меняет только переданные поля настроек, остальные берутся
+// из текущих настроек команды
+func (s *Service) UpdateSettings(ctx context.Context, req dto.UpdateTeamSettingsRequest) (_ *dto.TeamSettingsDTO, err error) {
+	ctx, span := tracing.Start(ctx, "teams.Service.UpdateSettings")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateTeamName(req.TeamName); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+
+	exists, err := s.teams.TeamExists(ctx, req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке существования команды: %v", err)
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// блокировка не даёт параллельному изменению потерять чужие поля
+		if err := s.teams.LockTeam(ctx, req.TeamName); err != nil {
+			return err
+		}
+
+		current, err := s.teams.GetTeamSettings(ctx, req.TeamName)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении настроек команды: %v", err)
+		}
+
+		settings := mergeSettings(*current, req)
+		if err := validateSettings(settings); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+		}
+		if err := checkApprovalGate(*current, settings, req.AllowNoApprovals); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+		}
+
+		if err := s.teams.UpsertTeamSettings(ctx, settings); err != nil {
+			return fmt.Errorf("ошибка при сохранении настроек команды: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.teams.GetTeamSettings(ctx, req.TeamName)
+}
+
+// mergeSettings накладывает переданные поля на текущие настройки. Неуказанные
+// зависимые пределы подтягиваются к новому required_reviewers, чтобы клиенты,
+// не знающие о новых полях, могли менять его как раньше
+func mergeSettings(current dto.TeamSettingsDTO, req dto.UpdateTeamSettingsRequest) dto.TeamSettingsDTO {
+	settings := current
+	settings.TeamName = req.TeamName
+
+	if req.RequiredReviewers != nil {
+		settings.RequiredReviewers = *req.RequiredReviewers
+	}
+	if req.MinReviewers != nil {
+		settings.MinReviewers = *req.MinReviewers
+	} else {
+		settings.MinReviewers = min(settings.MinReviewers, settings.RequiredReviewers)
+	}
+	if req.RequiredApprovals != nil {
+		settings.RequiredApprovals = *req.RequiredApprovals
+	} else {
+		settings.RequiredApprovals = min(settings.RequiredApprovals, settings.RequiredReviewers)
+	}
+	if req.MaxReviewers != nil {
+		settings.MaxReviewers = *req.MaxReviewers
+	} else {
+		settings.MaxReviewers = max(settings.MaxReviewers, settings.RequiredReviewers)
+	}
+	if req.Strategy != nil {
+		settings.Strategy = *req.Strategy
+	}
+	if req.AllowCrossTeamFallback != nil {
+		settings.AllowCrossTeamFallback = *req.AllowCrossTeamFallback
+	}
+	return settings
+}
+
+// checkApprovalGate не даёт отключить проверку одобрений случайно: перевод
+// required_approvals в 0, явный или из-за снижения required_reviewers,
+// требует allow_no_approvals
+func checkApprovalGate(current, settings dto.TeamSettingsDTO, allowNoApprovals bool) error {
+	if settings.RequiredApprovals == 0 && current.RequiredApprovals > 0 && !allowNoApprovals {
+		return errors.New("required_approvals 0 lets PRs merge without approvals; set allow_no_approvals to confirm")
+	}
+	return nil
+}
+
+func validateSettings(settings dto.TeamSettingsDTO) error {
+	if settings.RequiredReviewers < 0 || settings.RequiredReviewers > dto.MaxRequiredReviewers {
+		return fmt.Errorf("required_reviewers must be between 0 and %d", dto.MaxRequiredReviewers)
+	}
+	if settings.MinReviewers < 0 {
+		return errors.New("min_reviewers cannot be negative")
+	}
+	if settings.MinReviewers > settings.RequiredReviewers {
+		return errors.New("min_reviewers cannot exceed required_reviewers")
+	}
+	if settings.RequiredApprovals < 0 {
+		return errors.New("required_approvals cannot be negative")
+	}
+	if settings.RequiredApprovals > settings.RequiredReviewers {
+		return errors.New("required_approvals cannot exceed required_reviewers")
+	}
+	if settings.MaxReviewers < settings.RequiredReviewers || settings.MaxReviewers > dto.MaxRequiredReviewers {
+		return fmt.Errorf("max_reviewers must be between required_reviewers and %d", dto.MaxRequiredReviewers)
+	}
+	if settings.Strategy != "" && !slices.Contains(dto.Strategies, settings.Strategy) {
+		return fmt.Errorf("unknown strategy: %s", settings.Strategy)
+	}
+	return nil
+}
+
+func (s *Service) GetLeads(ctx context.Context, teamName string) (_ *dto.TeamLeadsDTO, err error) {
+	ctx, span := tracing.Start(ctx, "teams.Service.GetLeads")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateTeamName(teamName); err != nil {
+		return nil, fmt.Errorf("%w: invalid team_name: %v", ErrInvalidLeads, err)
+	}
+
+	exists, err := s.teams.TeamExists(ctx, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке существования команды: %v", err)
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	leads, err := s.leads.GetTeamLeads(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TeamLeadsDTO{TeamName: teamName, LeadIDs: leads}, nil
+}
+
+// SetLeads заменяет руководителей команды; руководителем может быть только
+// её участник
+func (s *Service) SetLeads(ctx context.Context, req dto.TeamLeadsDTO) (_ *dto.TeamLeadsDTO, err error) {
+	ctx, span := tracing.Start(ctx, "teams.Service.SetLeads")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateTeamName(req.TeamName); err != nil {
+		return nil, fmt.Errorf("%w: invalid team_name: %v", ErrInvalidLeads, err)
+	}
+	if len(req.LeadIDs) > maxTeamMembers {
+		return nil, fmt.Errorf("%w: too many lead_ids (max %d)", ErrInvalidLeads, maxTeamMembers)
+	}
+	if err := validator.ValidateMembersUnique(req.LeadIDs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLeads, err)
+	}
+
+	exists, err := s.teams.TeamExists(ctx, req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке существования команды: %v", err)
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	team, err := s.users.GetTeamByName(ctx, req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении участников команды: %v", err)
+	}
+	members := make(map[string]bool, len(team.Members))
+	for _, member := range team.Members {
+		members[member.UserID] = true
+	}
+	for _, userID := range req.LeadIDs {
+		if !members[userID] {
+			return nil, fmt.Errorf("%w: user %s is not a member of team %s", ErrInvalidLeads, userID, req.TeamName)
+		}
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.leads.ReplaceTeamLeads(ctx, req.TeamName, req.LeadIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении руководителей команды: %v", err)
+	}
+
+	return s.GetLeads(ctx, req.TeamName)
+}
+
+// DeactivateMembers выключает участников команды (всех или перечисленных)
+// и в одной транзакции передаёт их открытые ревью оставшимся активным
+func (s *Service) DeactivateMembers(ctx context.Context, req dto.DeactivateTeamRequest) (_ *dto.DeactivateTeamResponse, err error) {
+	ctx, span := tracing.Start(ctx, "teams.Service.DeactivateMembers")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateTeamName(req.TeamName); err != nil {
+		return nil, fmt.Errorf("%w: invalid team_name: %v", ErrInvalidMembers, err)
+	}
+	if len(req.UserIDs) > maxTeamMembers {
+		return nil, fmt.Errorf("%w: too many user_ids (max %d)", ErrInvalidMembers, maxTeamMembers)
+	}
+	if err := validator.ValidateMembersUnique(req.UserIDs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMembers, err)
+	}
+
+	exists, err := s.teams.TeamExists(ctx, req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при проверке существования команды: %v", err)
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+
+	var (
+		userIDs []string
+		plan    *dto.HandoffReportDTO
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// состав команды проверяется под блокировкой, которую держит и подбор
+		// замены, чтобы участников не успели перевести в другую команду
+		if err := s.teams.LockTeam(ctx, req.TeamName); err != nil {
+			return err
+		}
+
+		team, err := s.users.GetTeamByName(ctx, req.TeamName)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении участников команды: %v", err)
+		}
+
+		members := make(map[string]bool, len(team.Members))
+		for _, member := range team.Members {
+			members[member.UserID] = true
+		}
+
+		userIDs = req.UserIDs
+		if len(userIDs) == 0 {
+			userIDs = make([]string, 0, len(team.Members))
+			for _, member := range team.Members {
+				userIDs = append(userIDs, member.UserID)
+			}
+		}
+		for _, userID := range userIDs {
+			if !members[userID] {
+				return fmt.Errorf("%w: user %s is not a member of team %s", ErrInvalidMembers, userID, req.TeamName)
+			}
+		}
+
+		plan, err = s.handoff.PlanReviewHandoff(ctx, req.TeamName, userIDs)
+		if err != nil {
+			return fmt.Errorf("ошибка при подборе замены ревьюверам: %w", err)
+		}
+
+		plan, err = s.users.DeactivateUsers(ctx, userIDs, *plan)
+		if err != nil {
+			return err
+		}
+		return s.handoff.UpdateHandoffStaffing(ctx, req.TeamName, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ReviewersReassigned(metrics.ReassignDeactivation, len(plan.Reassigned))
+	metrics.NoCandidate(metrics.OperationHandoff, len(plan.NoCandidate))
+
+	return &dto.DeactivateTeamResponse{
+		TeamName:     req.TeamName,
+		Deactivated:  userIDs,
+		Reassignment: *plan,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package teams
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"testing"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestMergeSettings(t *testing.T) {
+	current := dto.TeamSettingsDTO{
+		TeamName:          "backend",
+		RequiredReviewers: 3,
+		MinReviewers:      2,
+		RequiredApprovals: 2,
+		MaxReviewers:      4,
+		Strategy:          dto.StrategyRoundRobin,
+	}
+
+	tests := []struct {
+		name string
+		req  dto.UpdateTeamSettingsRequest
+		want dto.TeamSettingsDTO
+	}{
+		{
+			name: "omitted fields keep current values",
+			req:  dto.UpdateTeamSettingsRequest{TeamName: "backend"},
+			want: current,
+		},
+		{
+			name: "lower required clamps inherited min and approvals",
+			req:  dto.UpdateTeamSettingsRequest{TeamName: "backend", RequiredReviewers: intPtr(1)},
+			want: dto.TeamSettingsDTO{
+				TeamName:          "backend",
+				RequiredReviewers: 1,
+				MinReviewers:      1,
+				RequiredApprovals: 1,
+				MaxReviewers:      4,
+				Strategy:          dto.StrategyRoundRobin,
+			},
+		},
+		{
+			name: "higher required raises inherited max",
+			req:  dto.UpdateTeamSettingsRequest{TeamName: "backend", RequiredReviewers: intPtr(5)},
+			want: dto.TeamSettingsDTO{
+				TeamName:          "backend",
+				RequiredReviewers: 5,
+				MinReviewers:      2,
+				RequiredApprovals: 2,
+				MaxReviewers:      5,
+				Strategy:          dto.StrategyRoundRobin,
+			},
+		},
+		{
+			name: "explicit values are not clamped",
+			req: dto.UpdateTeamSettingsRequest{
+				TeamName:          "backend",
+				RequiredReviewers: intPtr(1),
+				MinReviewers:      intPtr(2),
+				RequiredApprovals: intPtr(0),
+			},
+			want: dto.TeamSettingsDTO{
+				TeamName:          "backend",
+				RequiredReviewers: 1,
+				MinReviewers:      2,
+				RequiredApprovals: 0,
+				MaxReviewers:      4,
+				Strategy:          dto.StrategyRoundRobin,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeSettings(current, tt.req); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	valid := dto.TeamSettingsDTO{
+		TeamName:          "backend",
+		RequiredReviewers: 2,
+		MinReviewers:      1,
+		RequiredApprovals: 1,
+		MaxReviewers:      3,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *dto.TeamSettingsDTO)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*dto.TeamSettingsDTO) {}},
+		{name: "negative required", modify: func(s *dto.TeamSettingsDTO) { s.RequiredReviewers = -1 }, wantErr: true},
+		{name: "min above required", modify: func(s *dto.TeamSettingsDTO) { s.MinReviewers = 3 }, wantErr: true},
+		{name: "approvals above required", modify: func(s *dto.TeamSettingsDTO) { s.RequiredApprovals = 3 }, wantErr: true},
+		{name: "max below required", modify: func(s *dto.TeamSettingsDTO) { s.MaxReviewers = 1 }, wantErr: true},
+		{name: "unknown strategy", modify: func(s *dto.TeamSettingsDTO) { s.Strategy = "unknown" }, wantErr: true},
+		{name: "known strategy", modify: func(s *dto.TeamSettingsDTO) { s.Strategy = dto.StrategyWeighted }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			tt.modify(&settings)
+			if err := validateSettings(settings); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckApprovalGate(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		next     int
+		allow    bool
+		rejected bool
+	}{
+		{name: "keeps approvals", current: 1, next: 2},
+		{name: "disables without confirmation", current: 1, next: 0, rejected: true},
+		{name: "disables with confirmation", current: 2, next: 0, allow: true},
+		{name: "already disabled", current: 0, next: 0},
+		{name: "enables", current: 0, next: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkApprovalGate(
+				dto.TeamSettingsDTO{RequiredApprovals: tt.current},
+				dto.TeamSettingsDTO{RequiredApprovals: tt.next},
+				tt.allow,
+			)
+			if (err != nil) != tt.rejected {
+				t.Errorf("got error %v, want rejected=%v", err, tt.rejected)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package user
 import (
 	"AvitoTech/internal/domain/dto"
 	"AvitoTech/internal/domain/interfaces"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/internal/tracing"
 	"AvitoTech/pkg/validator"
 	"context"
 	"errors"
@@ -16,18 +18,24 @@ const (
 )
 
 var (
-	ErrUserNotFound = errors.New("пользователь не найден")
+	ErrUserNotFound    = errors.New("пользователь не найден")
+	ErrInvalidCapacity = errors.New("max_open_reviews must be null or non-negative")
 )
 
 type Service struct {
-	repo interfaces.UserRepository
+	repo    interfaces.UserRepository
+	tx      interfaces.TxManager
+	handoff interfaces.ReviewHandoffPlanner
 }
 
-func NewService(repo interfaces.UserRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo interfaces.UserRepository, tx interfaces.TxManager, handoff interfaces.ReviewHandoffPlanner) *Service {
+	return &Service{repo: repo, tx: tx, handoff: handoff}
 }
 
-func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]dto.PullRequestShortDTO, error) {
+func (s *Service) GetUserReviews(ctx context.Context, userID string) (_ []dto.PullRequestShortDTO, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.GetUserReviews")
+	defer func() { tracing.End(span, err) }()
+
 	reviews, err := s.repo.GetUserReviews(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -36,15 +44,78 @@ func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]dto.Pull
 	return reviews, nil
 }
 
-func (s *Service) SetUserActive(ctx context.Context, userID string, isActive bool) (*dto.UserDTO, error) {
+// SetUserActive меняет активность пользователя. При деактивации его открытые
+// ревью передаются другим участникам команды в одной транзакции, а в ответ
+// добавляется отчёт о переназначениях
+func (s *Service) SetUserActive(ctx context.Context, userID string, isActive bool) (_ *dto.SetUserActiveResponse, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.SetUserActive")
+	defer func() { tracing.End(span, err) }()
+
 	if err := validator.ValidateUserID(userID); err != nil {
 		return nil, fmt.Errorf("invalid user_id: %w", err)
 	}
 
-	user, err := s.repo.SetUserActive(ctx, userID, isActive)
+	current, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if isActive {
+		user, err := s.repo.SetUserActive(ctx, userID, isActive)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.SetUserActiveResponse{User: *user}, nil
+	}
+
+	var (
+		plan *dto.HandoffReportDTO
+		user *dto.UserDTO
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		plan, err = s.handoff.PlanReviewHandoff(ctx, current.TeamName, []string{userID})
+		if err != nil {
+			return fmt.Errorf("ошибка при подборе замены ревьюверу: %w", err)
+		}
+
+		plan, err = s.repo.DeactivateUsers(ctx, []string{userID}, *plan)
+		if err != nil {
+			return err
+		}
+		if err := s.handoff.UpdateHandoffStaffing(ctx, current.TeamName, plan); err != nil {
+			return err
+		}
+
+		user, err = s.repo.GetUser(ctx, userID)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	metrics.ReviewersReassigned(metrics.ReassignDeactivation, len(plan.Reassigned))
+	metrics.NoCandidate(metrics.OperationHandoff, len(plan.NoCandidate))
+
+	return &dto.SetUserActiveResponse{User: *user, Reassignment: plan}, nil
+}
+
+// SetMaxOpenReviews задаёт личный лимит открытых ревью пользователя:
+// nil возвращает глобальный reviewers.max_open_reviews, 0 снимает лимит
+func (s *Service) SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (_ *dto.UserDTO, err error) {
+	ctx, span := tracing.Start(ctx, "user.Service.SetMaxOpenReviews")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validator.ValidateUserID(userID); err != nil {
+		return nil, fmt.Errorf("invalid user_id: %w", err)
+	}
+	if limit != nil && *limit < 0 {
+		return nil, ErrInvalidCapacity
+	}
+
+	if _, err := s.repo.GetUser(ctx, userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return s.repo.SetMaxOpenReviews(ctx, userID, limit)
 }
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"AvitoTech/internal/domain/auth"
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// AuthMiddleware проверяет заголовок Authorization: Bearer <token> и кладёт
+// участника в контекст. С выключенной аутентификацией каждый запрос
+// выполняется с правами администратора
+func AuthMiddleware(authn Authenticator, enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !enabled {
+				ctx := auth.WithPrincipal(r.Context(), &auth.Principal{Role: auth.RoleAdmin})
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				writeAuthError(w, http.StatusUnauthorized, auth.Unauthorized, "missing bearer token")
+				return
+			}
+
+			principal, err := authn.Authenticate(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				if errors.Is(err, auth.ErrUnauthorized) {
+					logger.FromContext(r.Context()).Warn("Недействительный токен")
+					writeAuthError(w, http.StatusUnauthorized, auth.Unauthorized, "invalid or expired token")
+					return
+				}
+				logger.FromContext(r.Context()).Error("Ошибка при проверке токена", zap.Error(err))
+				writeAuthError(w, http.StatusInternalServerError, auth.InternalError, "internal server error")
+				return
+			}
+
+			trace.SpanFromContext(r.Context()).SetAttributes(
+				attribute.String("enduser.id", principal.UserID),
+				attribute.String("enduser.role", principal.Role),
+				attribute.String("auth.method", principal.Method),
+			)
+			l := logger.FromContext(r.Context()).With(
+				zap.String("auth_role", principal.Role),
+				zap.String("auth_user_id", principal.UserID),
+				zap.String("auth_method", principal.Method),
+			)
+			ctx := logger.WithContext(auth.WithPrincipal(r.Context(), principal), l)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireRole пропускает только участников с одной из ролей
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.PrincipalFromContext(r.Context())
+			if principal == nil {
+				writeAuthError(w, http.StatusUnauthorized, auth.Unauthorized, "authentication required")
+				return
+			}
+			if !slices.Contains(roles, principal.Role) {
+				logger.FromContext(r.Context()).Warn("Недостаточно прав",
+					zap.String("role", principal.Role),
+					zap.Strings("required", roles),
+				)
+				writeAuthError(w, http.StatusForbidden, auth.Forbidden, "insufficient permissions")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeAuthError(w http.ResponseWriter, status int, code, message string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="pr-service"`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+		Error: dto.Error{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"AvitoTech/internal/domain/auth"
+	"AvitoTech/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// maxScopedBodySize ограничивает тело, которое middleware читают целиком
+const maxScopedBodySize = 1 << 20
+
+type TeamAuthorizer interface {
+	AuthorizeTeam(ctx context.Context, p *auth.Principal, teamName string) error
+	AuthorizeUser(ctx context.Context, p *auth.Principal, userID string) error
+	AuthorizePR(ctx context.Context, p *auth.Principal, prID string) error
+	AuthorizePRAuthor(ctx context.Context, p *auth.Principal, prID string) error
+}
+
+// ScopeCheck проверяет права участника на команду, к которой относится запрос
+type ScopeCheck func(r *http.Request, p *auth.Principal) error
+
+var (
+	// errUnreadableBody - тело не разобрать, команду не определить
+	errUnreadableBody = errors.New("unreadable request body")
+	errBodyTooLarge   = errors.New("request body too large")
+)
+
+// RequireTeamScope пропускает администраторов и руководителей команды,
+// к которой относится запрос. Если команду определить нельзя (нет такого
+// пользователя или PR, невалидное тело), не-администратор получает 403, как
+// и на чужую команду, чтобы по ответу нельзя было узнать, существует ли
+// объект. Администратору в этом случае ответит 400/404 обработчик
+func RequireTeamScope(check ScopeCheck) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.PrincipalFromContext(r.Context())
+			if principal == nil {
+				writeAuthError(w, http.StatusUnauthorized, auth.Unauthorized, "authentication required")
+				return
+			}
+			if principal.IsAdmin() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err := check(r, principal)
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r)
+			case errors.Is(err, errBodyTooLarge):
+				writeAuthError(w, http.StatusRequestEntityTooLarge, auth.BadRequest, "request body too large")
+			case errors.Is(err, auth.ErrForbidden), errors.Is(err, auth.ErrScopeNotFound), errors.Is(err, errUnreadableBody):
+				logger.FromContext(r.Context()).Warn("Нет прав руководителя на команду запроса")
+				writeAuthError(w, http.StatusForbidden, auth.Forbidden, "team lead permissions required for this team")
+			default:
+				logger.FromContext(r.Context()).Error("Ошибка при проверке прав на команду", zap.Error(err))
+				writeAuthError(w, http.StatusInternalServerError, auth.InternalError, "internal server error")
+			}
+		})
+	}
+}
+
+// TeamFromQuery - команда из query-параметра team_name
+func TeamFromQuery(authz TeamAuthorizer) ScopeCheck {
+	return func(r *http.Request, p *auth.Principal) error {
+		return authz.AuthorizeTeam(r.Context(), p, r.URL.Query().Get("team_name"))
+	}
+}
+
+// TeamFromBody - команда из поля team_name тела запроса
+func TeamFromBody(authz TeamAuthorizer) ScopeCheck {
+	return func(r *http.Request, p *auth.Principal) error {
+		var body struct {
+			TeamName string `json:"team_name"`
+		}
+		if err := peekJSON(r, &body); err != nil {
+			return err
+		}
+		return authz.AuthorizeTeam(r.Context(), p, body.TeamName)
+	}
+}
+
+// UserFromBody - команда пользователя из поля user_id тела запроса
+func UserFromBody(authz TeamAuthorizer) ScopeCheck {
+	return func(r *http.Request, p *auth.Principal) error {
+		var body struct {
+			UserID string `json:"user_id"`
+		}
+		if err := peekJSON(r, &body); err != nil {
+			return err
+		}
+		return authz.AuthorizeUser(r.Context(), p, body.UserID)
+	}
+}
+
+// PRFromBody - команда автора PR из поля pull_request_id тела запроса
+func PRFromBody(authz TeamAuthorizer) ScopeCheck {
+	return func(r *http.Request, p *auth.Principal) error {
+		var body struct {
+			PullRequestID string `json:"pull_request_id"`
+		}
+		if err := peekJSON(r, &body); err != nil {
+			return err
+		}
+		return authz.AuthorizePR(r.Context(), p, body.PullRequestID)
+	}
+}
+
+// PRAuthorFromBody - как PRFromBody, но пропускает и автора PR
+func PRAuthorFromBody(authz TeamAuthorizer) ScopeCheck {
+	return func(r *http.Request, p *auth.Principal) error {
+		var body struct {
+			PullRequestID string `json:"pull_request_id"`
+		}
+		if err := peekJSON(r, &body); err != nil {
+			return err
+		}
+		return authz.AuthorizePRAuthor(r.Context(), p, body.PullRequestID)
+	}
+}
+
+// peekJSON разбирает тело тем же encoding/json, что и обработчики, и
+// возвращает его обратно в запрос: обработчик увидит те же байты и те же поля
+func peekJSON(r *http.Request, v any) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(v); err != nil {
+		return errUnreadableBody
+	}
+	return nil
+}
+
+// readBody читает тело целиком (не больше maxScopedBodySize) и подменяет
+// r.Body копией, чтобы его могли прочитать следующие обработчики
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxScopedBodySize+1))
+	if err != nil {
+		return nil, errUnreadableBody
+	}
+	if len(body) > maxScopedBodySize {
+		return nil, errBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
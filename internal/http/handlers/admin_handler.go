@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"encoding/json"
+	"net/http"
+)
+
+type PoolStatsProvider interface {
+	PoolStats() dto.PoolStatsDTO
+}
+
+// ConfigProvider отдаёт действующую конфигурацию с уже скрытыми секретами
+type ConfigProvider interface {
+	Redacted() map[string]any
+}
+
+type AdminHandler struct {
+	pool   PoolStatsProvider
+	config ConfigProvider
+}
+
+func NewAdminHandler(pool PoolStatsProvider, config ConfigProvider) *AdminHandler {
+	return &AdminHandler{pool: pool, config: config}
+}
+
+func (h *AdminHandler) GetPoolStats(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.PoolStatsResponse{Pool: h.pool.PoolStats()})
+}
+
+func (h *AdminHandler) GetConfig(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.ConfigResponse{Config: h.config.Redacted()})
+}
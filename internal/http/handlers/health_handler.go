@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/pkg/logger"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusStopping = "shutting_down"
+
+	healthCheckTimeout = 2 * time.Second
+)
+
+// HealthCheck - проверка одной зависимости. Check возвращает версию или
+// другую краткую информацию о зависимости
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) (string, error)
+}
+
+type HealthHandler struct {
+	checks       []HealthCheck
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+func (h *HealthHandler) Live(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.HealthResponse{Status: StatusOK})
+}
+
+// SetShuttingDown переводит readiness в "не готов", чтобы балансировщик
+// перестал направлять трафик до начала остановки сервера
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(dto.HealthResponse{Status: StatusStopping})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	results := make(map[string]dto.DependencyStatusDTO, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			info, err := check.Check(ctx)
+			status := dto.DependencyStatusDTO{
+				Status:    StatusUp,
+				LatencyMs: time.Since(start).Milliseconds(),
+				Info:      info,
+			}
+			if err != nil {
+				status.Status = StatusDown
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = status
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	response := dto.HealthResponse{Status: StatusReady, Checks: results}
+	code := http.StatusOK
+	for name, status := range results {
+		if status.Status != StatusUp {
+			logger.FromContext(r.Context()).Warn("Зависимость не готова",
+				zap.String("dependency", name),
+				zap.String("error", status.Error),
+			)
+			response.Status = StatusNotReady
+			code = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"AvitoTech/internal/domain/auth"
 	"AvitoTech/internal/domain/dto"
 	"AvitoTech/internal/domain/pr"
 	"AvitoTech/pkg/logger"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -22,7 +24,7 @@ func NewPRHandler(service *pr.Service) *PRHandler {
 func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreatePullRequestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Log.Warn("Неверный формат запроса создания PR", zap.Error(err))
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса создания PR", zap.Error(err))
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
@@ -34,16 +36,31 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Запрос на создание PR",
+	logger.FromContext(r.Context()).Info("Запрос на создание PR",
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("author_id", req.AuthorID),
 	)
 
+	if !auth.PrincipalFromContext(r.Context()).CanActAs(req.AuthorID) {
+		logger.FromContext(r.Context()).Warn("Попытка создать PR от имени другого пользователя",
+			zap.String("author_id", req.AuthorID),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.Forbidden,
+				Message: "author_id must match the authenticated user",
+			},
+		})
+		return
+	}
+
 	pullRequest, err := h.service.CreatePR(r.Context(), req)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		if errors.Is(err, pr.ErrPRExists) {
-			logger.Log.Warn("PR уже существует", zap.String("pr_id", req.PullRequestID))
+			logger.FromContext(r.Context()).Warn("PR уже существует", zap.String("pr_id", req.PullRequestID))
 			w.WriteHeader(http.StatusConflict)
 			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 				Error: dto.Error{
@@ -55,7 +72,7 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if errors.Is(err, pr.ErrAuthorNotFound) {
-			logger.Log.Warn("Автор не найден", zap.String("author_id", req.AuthorID))
+			logger.FromContext(r.Context()).Warn("Автор не найден", zap.String("author_id", req.AuthorID))
 			w.WriteHeader(http.StatusNotFound)
 			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 				Error: dto.Error{
@@ -66,7 +83,19 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		logger.Log.Error("Ошибка при создании PR", zap.Error(err))
+		if errors.Is(err, pr.ErrNotEnoughReviewers) {
+			logger.FromContext(r.Context()).Warn("Недостаточно ревьюверов для PR", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotEnough,
+					Message: "not enough active reviewers in team",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при создании PR", zap.Error(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 			Error: dto.Error{
@@ -77,7 +106,7 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("PR успешно создан",
+	logger.FromContext(r.Context()).Info("PR успешно создан",
 		zap.String("pr_id", pullRequest.PullRequestID),
 		zap.Int("reviewers_count", len(pullRequest.AssignedReviewers)),
 	)
@@ -90,7 +119,7 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	var req dto.MergePullRequestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Log.Warn("Неверный формат запроса мерджа PR", zap.Error(err))
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса мерджа PR", zap.Error(err))
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
@@ -102,14 +131,32 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Запрос на мердж PR", zap.String("pr_id", req.PullRequestID))
+	logger.FromContext(r.Context()).Info("Запрос на мердж PR",
+		zap.String("pr_id", req.PullRequestID),
+		zap.Bool("override_approvals", req.OverrideApprovals),
+	)
+
+	if req.OverrideApprovals && !auth.PrincipalFromContext(r.Context()).IsAdmin() {
+		logger.FromContext(r.Context()).Warn("Попытка мерджа без одобрений без прав администратора",
+			zap.String("pr_id", req.PullRequestID),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.Forbidden,
+				Message: "override_approvals requires admin role",
+			},
+		})
+		return
+	}
 
 	mergedPR, err := h.service.MergePR(r.Context(), req)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 
 		if errors.Is(err, pr.ErrPRNotFound) {
-			logger.Log.Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
+			logger.FromContext(r.Context()).Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
 			w.WriteHeader(http.StatusNotFound)
 			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 				Error: dto.Error{
@@ -120,7 +167,34 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		logger.Log.Error("Ошибка при мердже PR", zap.Error(err))
+		if errors.Is(err, pr.ErrInvalidTransition) {
+			logger.FromContext(r.Context()).Warn("Мердж недопустим в текущем статусе PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.Error(err),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.InvalidState,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrNotApproved) {
+			logger.FromContext(r.Context()).Warn("PR не одобрен", zap.String("pr_id", req.PullRequestID), zap.Error(err))
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotApproved,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при мердже PR", zap.Error(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 			Error: dto.Error{
@@ -131,7 +205,7 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("PR успешно смерджен",
+	logger.FromContext(r.Context()).Info("PR успешно смерджен",
 		zap.String("pr_id", mergedPR.PullRequestID),
 		zap.String("merged_at", mergedPR.MergedAt),
 	)
@@ -141,10 +215,132 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(dto.MergePullRequestResponse{PR: *mergedPR})
 }
 
+func (h *PRHandler) ReviewPR(w http.ResponseWriter, r *http.Request) {
+	var req dto.ReviewPullRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса ревью", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Запрос на ревью PR",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("reviewer_id", req.ReviewerID),
+		zap.String("verdict", req.Verdict),
+	)
+
+	if !auth.PrincipalFromContext(r.Context()).CanActAs(req.ReviewerID) {
+		logger.FromContext(r.Context()).Warn("Попытка отправить ревью от имени другого пользователя",
+			zap.String("reviewer_id", req.ReviewerID),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.Forbidden,
+				Message: "reviewer_id must match the authenticated user",
+			},
+		})
+		return
+	}
+
+	response, err := h.service.SubmitReview(r.Context(), req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+
+		if errors.Is(err, pr.ErrInvalidVerdict) {
+			logger.FromContext(r.Context()).Warn("Неверный вердикт", zap.String("verdict", req.Verdict))
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.BadRequest,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrPRNotFound) {
+			logger.FromContext(r.Context()).Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotFound,
+					Message: "pull request not found",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrPRMerged) {
+			logger.FromContext(r.Context()).Warn("Попытка ревью смердженного PR", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.PRMerged,
+					Message: "cannot review merged PR",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrInvalidTransition) {
+			logger.FromContext(r.Context()).Warn("Ревью недопустимо в текущем статусе PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.Error(err),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.InvalidState,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrNotAssigned) {
+			logger.FromContext(r.Context()).Warn("Ревьювер не назначен на PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("user_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotAssigned,
+					Message: "reviewer is not assigned to this PR",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при сохранении ревью", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
 func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 	var req dto.ReassignPullRequestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Log.Warn("Неверный формат запроса переназначения", zap.Error(err))
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса переназначения", zap.Error(err))
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
@@ -156,7 +352,7 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Запрос на переназначение ревьювера",
+	logger.FromContext(r.Context()).Info("Запрос на переназначение ревьювера",
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("old_user_id", req.OldUserID),
 	)
@@ -166,7 +362,7 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
 		if errors.Is(err, pr.ErrPRNotFound) {
-			logger.Log.Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
+			logger.FromContext(r.Context()).Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
 			w.WriteHeader(http.StatusNotFound)
 			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 				Error: dto.Error{
@@ -178,7 +374,7 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if errors.Is(err, pr.ErrPRMerged) {
-			logger.Log.Warn("Попытка переназначить на смердженный PR",
+			logger.FromContext(r.Context()).Warn("Попытка переназначить на смердженный PR",
 				zap.String("pr_id", req.PullRequestID),
 			)
 			w.WriteHeader(http.StatusConflict)
@@ -191,8 +387,23 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, pr.ErrInvalidTransition) {
+			logger.FromContext(r.Context()).Warn("Переназначение недопустимо в текущем статусе PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.Error(err),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.InvalidState,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
 		if errors.Is(err, pr.ErrNotAssigned) {
-			logger.Log.Warn("Ревьювер не назначен на PR",
+			logger.FromContext(r.Context()).Warn("Ревьювер не назначен на PR",
 				zap.String("pr_id", req.PullRequestID),
 				zap.String("user_id", req.OldUserID),
 			)
@@ -207,7 +418,7 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if errors.Is(err, pr.ErrNoCandidate) {
-			logger.Log.Warn("Нет кандидатов для замены", zap.String("pr_id", req.PullRequestID))
+			logger.FromContext(r.Context()).Warn("Нет кандидатов для замены", zap.String("pr_id", req.PullRequestID))
 			w.WriteHeader(http.StatusConflict)
 			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 				Error: dto.Error{
@@ -218,7 +429,7 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		logger.Log.Error("Ошибка при переназначении ревьювера", zap.Error(err))
+		logger.FromContext(r.Context()).Error("Ошибка при переназначении ревьювера", zap.Error(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 			Error: dto.Error{
@@ -229,7 +440,7 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Ревьювер успешно переназначен",
+	logger.FromContext(r.Context()).Info("Ревьювер успешно переназначен",
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("replaced_by", response.ReplacedBy),
 	)
@@ -238,3 +449,505 @@ func (h *PRHandler) ReassignPR(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(response)
 }
+
+func (h *PRHandler) DeclineReview(w http.ResponseWriter, r *http.Request) {
+	var req dto.DeclineReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса отказа от ревью", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Запрос на отказ от ревью",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("reviewer_id", req.ReviewerID),
+	)
+
+	if !auth.PrincipalFromContext(r.Context()).CanActAs(req.ReviewerID) {
+		logger.FromContext(r.Context()).Warn("Попытка отказаться от ревью от имени другого пользователя",
+			zap.String("reviewer_id", req.ReviewerID),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.Forbidden,
+				Message: "reviewer_id must match the authenticated user",
+			},
+		})
+		return
+	}
+
+	response, err := h.service.DeclineReview(r.Context(), req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+
+		if errors.Is(err, pr.ErrInvalidReason) {
+			logger.FromContext(r.Context()).Warn("Некорректная причина отказа", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.BadRequest,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrPRNotFound) {
+			logger.FromContext(r.Context()).Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotFound,
+					Message: "pull request not found",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrPRMerged) {
+			logger.FromContext(r.Context()).Warn("Попытка отказаться от ревью смердженного PR",
+				zap.String("pr_id", req.PullRequestID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.PRMerged,
+					Message: "cannot decline review on merged PR",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrInvalidTransition) {
+			logger.FromContext(r.Context()).Warn("Отказ от ревью недопустим в текущем статусе PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.Error(err),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.InvalidState,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrNotAssigned) {
+			logger.FromContext(r.Context()).Warn("Ревьювер не назначен на PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("user_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotAssigned,
+					Message: "reviewer is not assigned to this PR",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при отказе от ревью", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Ревьювер отказался от ревью",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("replaced_by", response.ReplacedBy),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (h *PRHandler) AddReviewer(w http.ResponseWriter, r *http.Request) {
+	h.changeReviewer(w, r, pr.ActionAddReviewer, h.service.AddReviewer)
+}
+
+func (h *PRHandler) RemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	h.changeReviewer(w, r, pr.ActionRemoveReviewer, h.service.RemoveReviewer)
+}
+
+// changeReviewer - общий обработчик /pullRequest/reviewers/add и /remove
+func (h *PRHandler) changeReviewer(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	apply func(context.Context, dto.ReviewerChangeRequest) (*dto.PullRequestDTO, error),
+) {
+	var req dto.ReviewerChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса изменения ревьюверов",
+			zap.String("action", action),
+			zap.Error(err),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Запрос на изменение ревьюверов",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("reviewer_id", req.ReviewerID),
+		zap.String("action", action),
+	)
+
+	pullRequest, err := apply(r.Context(), req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+
+		if errors.Is(err, pr.ErrPRNotFound) {
+			logger.FromContext(r.Context()).Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotFound,
+					Message: "pull request not found",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrReviewerNotFound) || errors.Is(err, pr.ErrAuthorNotFound) {
+			logger.FromContext(r.Context()).Warn("Пользователь не найден", zap.String("pr_id", req.PullRequestID), zap.Error(err))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotFound,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrPRMerged) {
+			logger.FromContext(r.Context()).Warn("Попытка изменить ревьюверов смердженного PR",
+				zap.String("pr_id", req.PullRequestID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.PRMerged,
+					Message: "cannot change reviewers on merged PR",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrInvalidTransition) {
+			logger.FromContext(r.Context()).Warn("Изменение ревьюверов недопустимо в текущем статусе PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.Error(err),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.InvalidState,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrReviewerInactive) {
+			logger.FromContext(r.Context()).Warn("Ревьювер неактивен",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.ReviewerInactive,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrReviewerIsAuthor) {
+			logger.FromContext(r.Context()).Warn("Автор не может быть ревьювером своего PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.ReviewerIsAuthor,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrAlreadyAssigned) {
+			logger.FromContext(r.Context()).Warn("Ревьювер уже назначен на PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.AlreadyAssigned,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrReviewerOtherTeam) {
+			logger.FromContext(r.Context()).Warn("Ревьювер не состоит в команде автора",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.ReviewerOtherTeam,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrReviewerDeclined) {
+			logger.FromContext(r.Context()).Warn("Ревьювер отказался от ревью этого PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.ReviewerDeclined,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrReviewerAtCapacity) {
+			logger.FromContext(r.Context()).Warn("Ревьювер исчерпал лимит открытых ревью",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.ReviewerAtCapacity,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrTooManyReviewers) {
+			logger.FromContext(r.Context()).Warn("Превышено максимальное число ревьюверов команды",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.TooManyReviewers,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrNotAssigned) {
+			logger.FromContext(r.Context()).Warn("Ревьювер не назначен на PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotAssigned,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrNotEnoughReviewers) {
+			logger.FromContext(r.Context()).Warn("Ревьюверов останется меньше min_reviewers команды",
+				zap.String("pr_id", req.PullRequestID),
+				zap.String("reviewer_id", req.ReviewerID),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotEnough,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при изменении ревьюверов",
+			zap.String("action", action),
+			zap.Error(err),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Ревьюверы изменены",
+		zap.String("pr_id", pullRequest.PullRequestID),
+		zap.Strings("reviewers", pullRequest.AssignedReviewers),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.PullRequestResponse{PR: *pullRequest})
+}
+
+func (h *PRHandler) ClosePR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, pr.ActionClose, h.service.ClosePR)
+}
+
+func (h *PRHandler) ReopenPR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, pr.ActionReopen, h.service.ReopenPR)
+}
+
+func (h *PRHandler) ReadyPR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, pr.ActionReady, h.service.ReadyPR)
+}
+
+// changeStatus - общий обработчик /pullRequest/close, /reopen и /ready
+func (h *PRHandler) changeStatus(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	apply func(context.Context, dto.PullRequestActionRequest) (*dto.PullRequestDTO, error),
+) {
+	var req dto.PullRequestActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса смены статуса PR",
+			zap.String("action", action),
+			zap.Error(err),
+		)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Запрос на смену статуса PR",
+		zap.String("pr_id", req.PullRequestID),
+		zap.String("action", action),
+	)
+
+	pullRequest, err := apply(r.Context(), req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+
+		if errors.Is(err, pr.ErrPRNotFound) {
+			logger.FromContext(r.Context()).Warn("PR не найден", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotFound,
+					Message: "pull request not found",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrAuthorNotFound) {
+			logger.FromContext(r.Context()).Warn("Автор PR не найден", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotFound,
+					Message: "author not found",
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrInvalidTransition) {
+			logger.FromContext(r.Context()).Warn("Недопустимая смена статуса PR",
+				zap.String("pr_id", req.PullRequestID),
+				zap.Error(err),
+			)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.InvalidState,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, pr.ErrNotEnoughReviewers) {
+			logger.FromContext(r.Context()).Warn("Недостаточно ревьюверов для PR", zap.String("pr_id", req.PullRequestID))
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    pr.NotEnough,
+					Message: "not enough active reviewers in team",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при смене статуса PR",
+			zap.String("action", action),
+			zap.Error(err),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    pr.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Статус PR изменён",
+		zap.String("pr_id", pullRequest.PullRequestID),
+		zap.String("status", pullRequest.Status),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.PullRequestResponse{PR: *pullRequest})
+}
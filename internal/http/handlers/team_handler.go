@@ -22,7 +22,7 @@ func NewTeamHandler(service *teams.Service) *TeamHandler {
 func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	var req dto.TeamDTO
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Log.Warn("Неверный формат запроса создания команды", zap.Error(err))
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса создания команды", zap.Error(err))
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 			Error: dto.Error{
@@ -33,7 +33,7 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Создание команды",
+	logger.FromContext(r.Context()).Info("Создание команды",
 		zap.String("team_name", req.TeamName),
 		zap.Int("members_count", len(req.Members)),
 	)
@@ -43,7 +43,7 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		if errors.Is(err, teams.ErrTeamExists) {
-			logger.Log.Warn("Команда уже существует", zap.String("team_name", req.TeamName))
+			logger.FromContext(r.Context()).Warn("Команда уже существует", zap.String("team_name", req.TeamName))
 			w.WriteHeader(http.StatusBadRequest)
 			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 				Error: dto.Error{
@@ -54,7 +54,7 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		logger.Log.Error("Ошибка при создании команды",
+		logger.FromContext(r.Context()).Error("Ошибка при создании команды",
 			zap.String("team_name", req.TeamName),
 			zap.Error(err),
 		)
@@ -68,7 +68,7 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Команда успешно создана", zap.String("team_name", req.TeamName))
+	logger.FromContext(r.Context()).Info("Команда успешно создана", zap.String("team_name", req.TeamName))
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated) // 201
@@ -78,7 +78,7 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		logger.Log.Warn("Запрос получения команды без team_name")
+		logger.FromContext(r.Context()).Warn("Запрос получения команды без team_name")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 			Error: dto.Error{
@@ -89,12 +89,12 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Получение команды", zap.String("team_name", teamName))
+	logger.FromContext(r.Context()).Info("Получение команды", zap.String("team_name", teamName))
 
 	ctx := r.Context()
 	t, err := h.service.GetTeam(ctx, teamName)
 	if err != nil {
-		logger.Log.Warn("Команда не найдена",
+		logger.FromContext(r.Context()).Warn("Команда не найдена",
 			zap.String("team_name", teamName),
 			zap.Error(err),
 		)
@@ -109,7 +109,7 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Команда успешно получена",
+	logger.FromContext(r.Context()).Info("Команда успешно получена",
 		zap.String("team_name", teamName),
 		zap.Int("members_count", len(t.Members)),
 	)
@@ -117,3 +117,245 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(t)
 }
+
+func (h *TeamHandler) GetTeamSettings(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		logger.FromContext(r.Context()).Warn("Запрос настроек команды без team_name")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.BadRequest,
+				Message: "team_name is required",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Получение настроек команды", zap.String("team_name", teamName))
+
+	settings, err := h.service.GetSettings(r.Context(), teamName)
+	if err != nil {
+		h.writeTeamError(w, r, teamName, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.TeamSettingsResponse{Settings: *settings})
+}
+
+func (h *TeamHandler) UpdateTeamSettings(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateTeamSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса изменения настроек команды", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Изменение настроек команды",
+		zap.String("team_name", req.TeamName),
+		zap.Any("changes", req),
+	)
+
+	settings, err := h.service.UpdateSettings(r.Context(), req)
+	if err != nil {
+		h.writeTeamError(w, r, req.TeamName, err)
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Настройки команды успешно изменены", zap.String("team_name", req.TeamName))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.TeamSettingsResponse{Settings: *settings})
+}
+
+func (h *TeamHandler) GetTeamLeads(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		logger.FromContext(r.Context()).Warn("Запрос руководителей команды без team_name")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.BadRequest,
+				Message: "team_name is required",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Получение руководителей команды", zap.String("team_name", teamName))
+
+	leads, err := h.service.GetLeads(r.Context(), teamName)
+	if err != nil {
+		h.writeTeamError(w, r, teamName, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.TeamLeadsResponse{Leads: *leads})
+}
+
+func (h *TeamHandler) SetTeamLeads(w http.ResponseWriter, r *http.Request) {
+	var req dto.TeamLeadsDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса изменения руководителей команды", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Изменение руководителей команды",
+		zap.String("team_name", req.TeamName),
+		zap.Strings("lead_ids", req.LeadIDs),
+	)
+
+	leads, err := h.service.SetLeads(r.Context(), req)
+	if err != nil {
+		h.writeTeamError(w, r, req.TeamName, err)
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Руководители команды успешно изменены", zap.String("team_name", req.TeamName))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.TeamLeadsResponse{Leads: *leads})
+}
+
+func (h *TeamHandler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
+	var req dto.DeactivateTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса деактивации команды", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Деактивация участников команды",
+		zap.String("team_name", req.TeamName),
+		zap.Int("users_count", len(req.UserIDs)),
+	)
+
+	resp, err := h.service.DeactivateMembers(r.Context(), req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+
+		if errors.Is(err, teams.ErrInvalidMembers) {
+			logger.FromContext(r.Context()).Warn("Некорректный запрос деактивации команды",
+				zap.String("team_name", req.TeamName),
+				zap.Error(err),
+			)
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    teams.BadRequest,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, teams.ErrTeamNotFound) {
+			logger.FromContext(r.Context()).Warn("Команда не найдена", zap.String("team_name", req.TeamName))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    teams.TeamNotFound,
+					Message: "team not found",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при деактивации команды",
+			zap.String("team_name", req.TeamName),
+			zap.Error(err),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Участники команды деактивированы",
+		zap.String("team_name", req.TeamName),
+		zap.Int("deactivated", len(resp.Deactivated)),
+		zap.Int("reassigned", len(resp.Reassignment.Reassigned)),
+		zap.Int("no_candidate", len(resp.Reassignment.NoCandidate)),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *TeamHandler) writeTeamError(w http.ResponseWriter, r *http.Request, teamName string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if errors.Is(err, teams.ErrInvalidSettings) || errors.Is(err, teams.ErrInvalidLeads) {
+		logger.FromContext(r.Context()).Warn("Некорректный запрос к команде",
+			zap.String("team_name", teamName),
+			zap.Error(err),
+		)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.BadRequest,
+				Message: err.Error(),
+			},
+		})
+		return
+	}
+
+	if errors.Is(err, teams.ErrTeamNotFound) {
+		logger.FromContext(r.Context()).Warn("Команда не найдена", zap.String("team_name", teamName))
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    teams.TeamNotFound,
+				Message: "team not found",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Error("Ошибка при работе с командой",
+		zap.String("team_name", teamName),
+		zap.Error(err),
+	)
+	w.WriteHeader(http.StatusInternalServerError)
+	_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+		Error: dto.Error{
+			Code:    teams.InternalError,
+			Message: "internal server error",
+		},
+	})
+}
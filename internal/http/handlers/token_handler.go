@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"AvitoTech/internal/domain/auth"
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/pkg/logger"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type TokenHandler struct {
+	service *auth.Service
+}
+
+func NewTokenHandler(service *auth.Service) *TokenHandler {
+	return &TokenHandler{service: service}
+}
+
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса создания токена", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Запрос на создание токена",
+		zap.String("name", req.Name),
+		zap.String("role", req.Role),
+		zap.String("user_id", req.UserID),
+	)
+
+	resp, err := h.service.CreateToken(r.Context(), req)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, auth.ErrInvalidToken) {
+			logger.FromContext(r.Context()).Warn("Некорректный запрос создания токена", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    auth.BadRequest,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, auth.ErrUserNotFound) {
+			logger.FromContext(r.Context()).Warn("Пользователь для токена не найден", zap.String("user_id", req.UserID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    auth.NotFound,
+					Message: "user not found",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при создании токена", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.service.ListTokens(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Ошибка при получении токенов", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.TokensResponse{Tokens: tokens})
+}
+
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	var req dto.RevokeTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса отзыва токена", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	if err := h.service.RevokeToken(r.Context(), req.TokenID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, auth.ErrInvalidToken) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    auth.BadRequest,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, auth.ErrTokenNotFound) {
+			logger.FromContext(r.Context()).Warn("Токен для отзыва не найден", zap.String("token_id", req.TokenID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    auth.NotFound,
+					Message: "token not found or already revoked",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка при отзыве токена", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"AvitoTech/internal/domain/auth"
 	"AvitoTech/internal/domain/dto"
 	"AvitoTech/internal/domain/user"
 	"AvitoTech/pkg/logger"
@@ -23,7 +24,7 @@ func (h *UserHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 
 	if userID == "" {
-		logger.Log.Warn("Запрос получения PR без user_id")
+		logger.FromContext(r.Context()).Warn("Запрос получения PR без user_id")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
@@ -35,11 +36,24 @@ func (h *UserHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Получение PR пользователя", zap.String("user_id", userID))
+	if !auth.PrincipalFromContext(r.Context()).CanActAs(userID) {
+		logger.FromContext(r.Context()).Warn("Попытка получить чужие ревью", zap.String("user_id", userID))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    auth.Forbidden,
+				Message: "can only read own reviews",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Получение PR пользователя", zap.String("user_id", userID))
 
 	reviews, err := h.service.GetUserReviews(r.Context(), userID)
 	if err != nil {
-		logger.Log.Error("Ошибка получения PR пользователя",
+		logger.FromContext(r.Context()).Error("Ошибка получения PR пользователя",
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
@@ -54,7 +68,7 @@ func (h *UserHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("PR пользователя успешно получены",
+	logger.FromContext(r.Context()).Info("PR пользователя успешно получены",
 		zap.String("user_id", userID),
 		zap.Int("pr_count", len(reviews)),
 	)
@@ -72,7 +86,7 @@ func (h *UserHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 	var req dto.SetUserActiveRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Log.Warn("Неверный формат запроса изменения активности", zap.Error(err))
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса изменения активности", zap.Error(err))
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
@@ -84,17 +98,17 @@ func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Изменение активности пользователя",
+	logger.FromContext(r.Context()).Info("Изменение активности пользователя",
 		zap.String("user_id", req.UserID),
 		zap.Bool("is_active", req.IsActive),
 	)
 
-	u, err := h.service.SetUserActive(r.Context(), req.UserID, req.IsActive)
+	resp, err := h.service.SetUserActive(r.Context(), req.UserID, req.IsActive)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 
 		if errors.Is(err, user.ErrUserNotFound) {
-			logger.Log.Warn("Пользователь не найден", zap.String("user_id", req.UserID))
+			logger.FromContext(r.Context()).Warn("Пользователь не найден", zap.String("user_id", req.UserID))
 			w.WriteHeader(http.StatusNotFound)
 			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
 				Error: dto.Error{
@@ -105,7 +119,7 @@ func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		logger.Log.Error("Ошибка изменения активности пользователя",
+		logger.FromContext(r.Context()).Error("Ошибка изменения активности пользователя",
 			zap.String("user_id", req.UserID),
 			zap.Error(err),
 		)
@@ -119,12 +133,88 @@ func (h *UserHandler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Log.Info("Активность пользователя успешно изменена",
+	fields := []zap.Field{
 		zap.String("user_id", req.UserID),
 		zap.Bool("is_active", req.IsActive),
+	}
+	if resp.Reassignment != nil {
+		fields = append(fields,
+			zap.Int("reassigned", len(resp.Reassignment.Reassigned)),
+			zap.Int("no_candidate", len(resp.Reassignment.NoCandidate)),
+		)
+	}
+	logger.FromContext(r.Context()).Info("Активность пользователя успешно изменена", fields...)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *UserHandler) SetMaxOpenReviews(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetMaxOpenReviewsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.FromContext(r.Context()).Warn("Неверный формат запроса изменения лимита ревью", zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    user.BadRequest,
+				Message: "invalid request body",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Изменение лимита открытых ревью",
+		zap.String("user_id", req.UserID),
+		zap.Any("max_open_reviews", req.MaxOpenReviews),
 	)
 
+	updated, err := h.service.SetMaxOpenReviews(r.Context(), req.UserID, req.MaxOpenReviews)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+
+		if errors.Is(err, user.ErrInvalidCapacity) {
+			logger.FromContext(r.Context()).Warn("Неверный лимит открытых ревью", zap.String("user_id", req.UserID))
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    user.BadRequest,
+					Message: err.Error(),
+				},
+			})
+			return
+		}
+
+		if errors.Is(err, user.ErrUserNotFound) {
+			logger.FromContext(r.Context()).Warn("Пользователь не найден", zap.String("user_id", req.UserID))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+				Error: dto.Error{
+					Code:    user.UserNotFound,
+					Message: "user not found",
+				},
+			})
+			return
+		}
+
+		logger.FromContext(r.Context()).Error("Ошибка изменения лимита открытых ревью",
+			zap.String("user_id", req.UserID),
+			zap.Error(err),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+			Error: dto.Error{
+				Code:    user.InternalError,
+				Message: "internal server error",
+			},
+		})
+		return
+	}
+
+	logger.FromContext(r.Context()).Info("Лимит открытых ревью успешно изменён", zap.String("user_id", req.UserID))
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(dto.UserResponse{User: *u})
+	_ = json.NewEncoder(w).Encode(dto.UserResponse{User: *updated})
 }
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/domain/idempotency"
+	"AvitoTech/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyStoreTimeout  = 5 * time.Second
+)
+
+type IdempotencyStore interface {
+	Begin(ctx context.Context, record dto.IdempotencyRecordDTO) (*dto.IdempotencyRecordDTO, error)
+	Complete(ctx context.Context, record dto.IdempotencyRecordDTO) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key на POST:
+// первый ответ сохраняется вместе с хешем запроса, повторы с тем же телом
+// получают его же, с другим телом - 409. Ответы 5xx и помеченные
+// Cache-Control: no-store (например, с секретом токена) не сохраняются.
+// Ключи действуют в пределах клиента (токена или IP), как и лимиты.
+// С store == nil ничего не делает
+func IdempotencyMiddleware(store IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := idempotency.ValidateKey(key); err != nil {
+				writeIdempotencyError(w, http.StatusBadRequest, idempotency.BadRequest, err.Error())
+				return
+			}
+
+			body, err := readBody(r)
+			if err != nil {
+				if errors.Is(err, errBodyTooLarge) {
+					writeIdempotencyError(w, http.StatusRequestEntityTooLarge, idempotency.BadRequest, "request body too large")
+					return
+				}
+				writeIdempotencyError(w, http.StatusBadRequest, idempotency.BadRequest, "invalid request body")
+				return
+			}
+
+			record := dto.IdempotencyRecordDTO{
+				Scope:       clientKey(r),
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: idempotency.RequestHash(r.Method, r.URL.Path, body),
+			}
+			l := logger.FromContext(r.Context()).With(zap.String("idempotency_key", key))
+
+			stored, err := store.Begin(r.Context(), record)
+			switch {
+			case errors.Is(err, idempotency.ErrKeyMismatch):
+				l.Warn("Ключ идемпотентности использован с другим запросом")
+				writeIdempotencyError(w, http.StatusConflict, idempotency.KeyMismatch, "idempotency key already used with a different request")
+				return
+			case errors.Is(err, idempotency.ErrKeyInProgress):
+				l.Warn("Запрос с этим ключом идемпотентности ещё выполняется")
+				w.Header().Set("Retry-After", "1")
+				writeIdempotencyError(w, http.StatusConflict, idempotency.KeyInProgress, "request with this idempotency key is in progress")
+				return
+			case err != nil:
+				l.Error("Ошибка при проверке ключа идемпотентности", zap.Error(err))
+				writeIdempotencyError(w, http.StatusInternalServerError, idempotency.InternalError, "internal server error")
+				return
+			case stored != nil:
+				l.Info("Повтор запроса, возвращается сохранённый ответ", zap.Int("status", stored.StatusCode))
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set(idempotentReplayedHeader, "true")
+				w.WriteHeader(stored.StatusCode)
+				_, _ = w.Write(stored.Body)
+				return
+			}
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			handled := false
+			defer func() {
+				// ключ не должен остаться занятым, если обработчик запаниковал
+				if !handled {
+					releaseKey(r.Context(), store, record, l)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+			handled = true
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			noStore := strings.Contains(ww.Header().Get("Cache-Control"), "no-store")
+			if status >= http.StatusInternalServerError || noStore {
+				releaseKey(r.Context(), store, record, l)
+				return
+			}
+
+			record.StatusCode = status
+			record.ContentType = ww.Header().Get("Content-Type")
+			record.Body = buf.Bytes()
+
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreTimeout)
+			defer cancel()
+			if err := store.Complete(ctx, record); err != nil {
+				// ключ не освобождаем: повтор выполнил бы запрос второй раз.
+				// Он освободится сам через lock_timeout
+				l.Error("Не удалось сохранить ответ по ключу идемпотентности", zap.Error(err))
+			}
+		})
+	}
+}
+
+func releaseKey(ctx context.Context, store IdempotencyStore, record dto.IdempotencyRecordDTO, l *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+	defer cancel()
+	if err := store.Release(ctx, record.Scope, record.Key); err != nil {
+		l.Error("Не удалось освободить ключ идемпотентности", zap.Error(err))
+	}
+}
+
+func writeIdempotencyError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+		Error: dto.Error{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
@@ -0,0 +1,208 @@
+package http
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/domain/idempotency"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memIdempotencyRepo хранит ключи в памяти; срок жизни ключей не учитывается
+type memIdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[string]dto.IdempotencyRecordDTO
+}
+
+func newMemIdempotencyRepo() *memIdempotencyRepo {
+	return &memIdempotencyRepo{records: make(map[string]dto.IdempotencyRecordDTO)}
+}
+
+func (r *memIdempotencyRepo) Reserve(_ context.Context, record dto.IdempotencyRecordDTO, _, _ time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[record.Scope+" "+record.Key]; ok {
+		return false, nil
+	}
+	r.records[record.Scope+" "+record.Key] = record
+	return true, nil
+}
+
+func (r *memIdempotencyRepo) Get(_ context.Context, scope, key string) (*dto.IdempotencyRecordDTO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[scope+" "+key]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *memIdempotencyRepo) SaveResponse(_ context.Context, record dto.IdempotencyRecordDTO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.Scope+" "+record.Key] = record
+	return nil
+}
+
+func (r *memIdempotencyRepo) Delete(_ context.Context, scope, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, scope+" "+key)
+	return nil
+}
+
+func (r *memIdempotencyRepo) DeleteExpired(context.Context) (int64, error) {
+	return 0, nil
+}
+
+type idempotentCall struct {
+	method string
+	key    string
+	body   string
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	first := idempotentCall{method: http.MethodPost, key: "key-1", body: `{"pull_request_id":"pr-1"}`}
+
+	tests := []struct {
+		name         string
+		status       int
+		noStore      bool
+		inProgress   bool
+		calls        []idempotentCall
+		wantCodes    []int
+		wantErrCode  string
+		wantHandled  int
+		wantReplayed bool
+	}{
+		{
+			name:         "replay returns the stored response",
+			status:       http.StatusCreated,
+			calls:        []idempotentCall{first, first},
+			wantCodes:    []int{http.StatusCreated, http.StatusCreated},
+			wantHandled:  1,
+			wantReplayed: true,
+		},
+		{
+			name:   "same key with another body conflicts",
+			status: http.StatusCreated,
+			calls: []idempotentCall{
+				first,
+				{method: http.MethodPost, key: "key-1", body: `{"pull_request_id":"pr-2"}`},
+			},
+			wantCodes:   []int{http.StatusCreated, http.StatusConflict},
+			wantErrCode: idempotency.KeyMismatch,
+			wantHandled: 1,
+		},
+		{
+			name:        "request in progress conflicts",
+			status:      http.StatusOK,
+			inProgress:  true,
+			calls:       []idempotentCall{first},
+			wantCodes:   []int{http.StatusConflict},
+			wantErrCode: idempotency.KeyInProgress,
+			wantHandled: 0,
+		},
+		{
+			name:        "5xx is not stored",
+			status:      http.StatusInternalServerError,
+			calls:       []idempotentCall{first, first},
+			wantCodes:   []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			wantHandled: 2,
+		},
+		{
+			name:        "no-store is not stored",
+			status:      http.StatusCreated,
+			noStore:     true,
+			calls:       []idempotentCall{first, first},
+			wantCodes:   []int{http.StatusCreated, http.StatusCreated},
+			wantHandled: 2,
+		},
+		{
+			name:        "different keys execute separately",
+			status:      http.StatusOK,
+			calls:       []idempotentCall{first, {method: http.MethodPost, key: "key-2", body: first.body}},
+			wantCodes:   []int{http.StatusOK, http.StatusOK},
+			wantHandled: 2,
+		},
+		{
+			name:        "GET is not deduplicated",
+			status:      http.StatusOK,
+			calls:       []idempotentCall{{method: http.MethodGet, key: "key-1"}, {method: http.MethodGet, key: "key-1"}},
+			wantCodes:   []int{http.StatusOK, http.StatusOK},
+			wantHandled: 2,
+		},
+		{
+			name:        "invalid key",
+			status:      http.StatusOK,
+			calls:       []idempotentCall{{method: http.MethodPost, key: "key with spaces", body: first.body}},
+			wantCodes:   []int{http.StatusBadRequest},
+			wantErrCode: idempotency.BadRequest,
+			wantHandled: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemIdempotencyRepo()
+			if tt.inProgress {
+				repo.records["ip:192.0.2.1 "+first.key] = dto.IdempotencyRecordDTO{
+					Scope:       "ip:192.0.2.1",
+					Key:         first.key,
+					RequestHash: idempotency.RequestHash(first.method, "/pullRequest/create", []byte(first.body)),
+				}
+			}
+			store := idempotency.NewService(repo, time.Hour, time.Minute)
+
+			handled := 0
+			handler := IdempotencyMiddleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled++
+				if tt.noStore {
+					w.Header().Set("Cache-Control", "no-store")
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(handled) + `}`))
+			}))
+
+			var last *httptest.ResponseRecorder
+			for i, call := range tt.calls {
+				r := httptest.NewRequest(call.method, "/pullRequest/create", strings.NewReader(call.body))
+				r.RemoteAddr = "192.0.2.1:54321"
+				r.Header.Set(idempotencyKeyHeader, call.key)
+				last = httptest.NewRecorder()
+				handler.ServeHTTP(last, r)
+
+				if last.Code != tt.wantCodes[i] {
+					t.Fatalf("call %d: status %d, want %d", i, last.Code, tt.wantCodes[i])
+				}
+			}
+
+			if handled != tt.wantHandled {
+				t.Errorf("handler called %d times, want %d", handled, tt.wantHandled)
+			}
+			if got := last.Header().Get(idempotentReplayedHeader) == "true"; got != tt.wantReplayed {
+				t.Errorf("replayed header %v, want %v", got, tt.wantReplayed)
+			}
+			if tt.wantReplayed && last.Body.String() != `{"call":1}` {
+				t.Errorf("replayed body %s, want the first response", last.Body.String())
+			}
+			if tt.wantErrCode != "" {
+				var resp dto.ErrorResponse
+				if err := json.NewDecoder(last.Body).Decode(&resp); err != nil {
+					t.Fatalf("decode error response: %v", err)
+				}
+				if resp.Error.Code != tt.wantErrCode {
+					t.Errorf("error code %s, want %s", resp.Error.Code, tt.wantErrCode)
+				}
+			}
+		})
+	}
+}
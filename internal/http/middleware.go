@@ -0,0 +1,166 @@
+package http
+
+import (
+	"AvitoTech/internal/metrics"
+	"AvitoTech/pkg/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	tracerName = "AvitoTech/http"
+
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// RequestIDMiddleware берёт X-Request-ID из запроса или генерирует новый,
+// возвращает его в ответе и кладёт в контекст дочерний логгер с request_id
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		l := logger.FromContext(r.Context()).With(zap.String("request_id", requestID))
+		next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context(), l)))
+	})
+}
+
+// TracingMiddleware продолжает трассу из заголовка traceparent или начинает
+// новую, открывает серверный спан на весь обработчик и добавляет trace_id в
+// логгер запроса
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(tracerName).Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			l := logger.FromContext(ctx).With(zap.String("trace_id", sc.TraceID().String()))
+			ctx = logger.WithContext(ctx, l)
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := routePattern(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// AccessLogMiddleware пишет одну строку на запрос через логгер запроса
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		fields := []zap.Field{
+			zap.String("method", r.Method),
+			zap.String("route", routePattern(r)),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", status),
+			zap.Duration("duration", time.Since(start)),
+			zap.Int("bytes", ww.BytesWritten()),
+			zap.String("remote_addr", r.RemoteAddr),
+		}
+
+		l := logger.FromContext(r.Context())
+		if status >= http.StatusInternalServerError {
+			l.Error("HTTP запрос", fields...)
+		} else {
+			l.Info("HTTP запрос", fields...)
+		}
+	})
+}
+
+// validRequestID отбрасывает слишком длинные и непечатаемые значения, чтобы
+// клиент не мог испортить логи через заголовок
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// unmatchedRoute подставляется вместо пути для запросов, не попавших ни в
+// один маршрут, чтобы произвольные URL не раздували число рядов метрик
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware считает запросы и их длительность по шаблону маршрута chi
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			// обработчик ничего не записал - net/http отдаст 200
+			status = http.StatusOK
+		}
+		metrics.ObserveHTTPRequest(r.Method, routePattern(r), status, time.Since(start))
+	})
+}
+
+// routePattern возвращает шаблон маршрута; заполняется роутером только
+// после обработки запроса
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
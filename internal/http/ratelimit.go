@@ -0,0 +1,184 @@
+package http
+
+import (
+	"AvitoTech/internal/domain/auth"
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"AvitoTech/pkg/logger"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	RateLimited = "RATE_LIMITED"
+
+	// defaultRouteKey - общий лимит для путей без собственного
+	defaultRouteKey = "default"
+	// bucketSweepInterval - как часто удалять заполненные (неактивные) вёдра
+	bucketSweepInterval = time.Minute
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter - token bucket на пару (клиент, путь). Лимит задаётся в
+// запросах в минуту, ёмкость ведра равна лимиту
+type RateLimiter struct {
+	defaultLimit int
+	routes       map[string]int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewRateLimiter(perMinute int, routes map[string]int) *RateLimiter {
+	return &RateLimiter{
+		defaultLimit: perMinute,
+		routes:       routes,
+		buckets:      make(map[string]*bucket),
+		lastSweep:    time.Now(),
+	}
+}
+
+type rateDecision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	retryAfter time.Duration
+	reset      time.Duration
+}
+
+// limitFor возвращает ключ лимита и лимит для пути запроса
+func (l *RateLimiter) limitFor(path string) (string, int) {
+	if limit, ok := l.routes[path]; ok {
+		return path, limit
+	}
+	return defaultRouteKey, l.defaultLimit
+}
+
+func (l *RateLimiter) take(client, route string, limit int, now time.Time) rateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := float64(limit) / 60
+	l.sweep(now)
+
+	key := client + " " + route
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	d := rateDecision{limit: limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.allowed = true
+	} else {
+		d.retryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	d.remaining = int(b.tokens)
+	d.reset = time.Duration((float64(limit) - b.tokens) / rate * float64(time.Second))
+	return d
+}
+
+// sweep удаляет вёдра, которые успели бы заполниться: для клиента они
+// неотличимы от новых. Вызывается под l.mu
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware ограничивает частоту запросов по API-токену (или
+// пользователю JWT), а без аутентификации - по IP клиента. Ставится после
+// AuthMiddleware. С limiter == nil ничего не ограничивает
+func RateLimitMiddleware(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return rateLimitBy(limiter, clientKey)
+}
+
+// IPRateLimitMiddleware ограничивает частоту запросов по IP клиента. Ставится
+// перед AuthMiddleware, чтобы запросы с неверными токенами тоже
+// ограничивались и перебор токенов не шёл без предела
+func IPRateLimitMiddleware(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return rateLimitBy(limiter, ipKey)
+}
+
+func rateLimitBy(limiter *RateLimiter, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, limit := limiter.limitFor(r.URL.Path)
+			d := limiter.take(key(r), route, limit, time.Now())
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(d.reset)))
+
+			if !d.allowed {
+				metrics.RateLimited(route)
+				logger.FromContext(r.Context()).Warn("Превышен лимит запросов",
+					zap.String("limit_route", route),
+					zap.Int("limit", d.limit),
+				)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d.retryAfter)))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_ = json.NewEncoder(w).Encode(dto.ErrorResponse{
+					Error: dto.Error{
+						Code:    RateLimited,
+						Message: "rate limit exceeded, retry later",
+					},
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey - ключ лимита и области идемпотентности: API-токен, пользователь
+// JWT (не сам токен, иначе обновление токена давало бы новый лимит) или IP
+func clientKey(r *http.Request) string {
+	if p := auth.PrincipalFromContext(r.Context()); p != nil {
+		if p.Method == auth.MethodAPIToken && p.TokenID != "" {
+			return "token:" + p.TokenID
+		}
+		if p.UserID != "" {
+			return "user:" + p.UserID
+		}
+	}
+	return ipKey(r)
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
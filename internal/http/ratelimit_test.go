@@ -0,0 +1,191 @@
+package http
+
+import (
+	"AvitoTech/internal/domain/auth"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		limit         int
+		calls         []time.Duration
+		wantAllowed   []bool
+		wantRemaining int
+	}{
+		{
+			name:          "burst up to the limit",
+			limit:         3,
+			calls:         []time.Duration{0, 0, 0, 0},
+			wantAllowed:   []bool{true, true, true, false},
+			wantRemaining: 0,
+		},
+		{
+			name:          "refills over time",
+			limit:         60,
+			calls:         append(repeat(0, 60), time.Second),
+			wantAllowed:   append(repeatBool(true, 60), true),
+			wantRemaining: 0,
+		},
+		{
+			name:          "partial refill is not enough",
+			limit:         2,
+			calls:         []time.Duration{0, 0, 10 * time.Second},
+			wantAllowed:   []bool{true, true, false},
+			wantRemaining: 0,
+		},
+		{
+			name:          "never exceeds capacity",
+			limit:         2,
+			calls:         []time.Duration{0, time.Hour},
+			wantAllowed:   []bool{true, true},
+			wantRemaining: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.limit, nil)
+			l.lastSweep = start
+
+			var d rateDecision
+			for i, offset := range tt.calls {
+				d = l.take("ip:10.0.0.1", defaultRouteKey, tt.limit, start.Add(offset))
+				if d.allowed != tt.wantAllowed[i] {
+					t.Fatalf("call %d: allowed %v, want %v", i, d.allowed, tt.wantAllowed[i])
+				}
+			}
+			if d.remaining != tt.wantRemaining {
+				t.Errorf("remaining %d, want %d", d.remaining, tt.wantRemaining)
+			}
+			if !d.allowed && d.retryAfter <= 0 {
+				t.Errorf("denied without Retry-After")
+			}
+		})
+	}
+}
+
+func TestRateLimiterKeysAreIndependent(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter(1, map[string]int{"/pullRequest/create": 1})
+
+	if !l.take("token:a", defaultRouteKey, 1, now).allowed {
+		t.Fatal("first request of client a denied")
+	}
+	if !l.take("token:b", defaultRouteKey, 1, now).allowed {
+		t.Error("client b shares the bucket of client a")
+	}
+	if !l.take("token:a", "/pullRequest/create", 1, now).allowed {
+		t.Error("route limit shares the default bucket")
+	}
+	if l.take("token:a", defaultRouteKey, 1, now).allowed {
+		t.Error("second request of client a allowed")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		idle        time.Duration
+		sweepAt     time.Duration
+		wantBuckets int
+	}{
+		{name: "sweep not due", idle: 0, sweepAt: 30 * time.Second, wantBuckets: 2},
+		{name: "idle bucket removed", idle: 0, sweepAt: 2 * time.Minute, wantBuckets: 1},
+		{name: "recent bucket kept", idle: 90 * time.Second, sweepAt: 2 * time.Minute, wantBuckets: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(10, nil)
+			l.lastSweep = start
+
+			l.take("ip:idle", defaultRouteKey, 10, start.Add(tt.idle))
+			l.take("ip:active", defaultRouteKey, 10, start.Add(tt.sweepAt))
+
+			if got := len(l.buckets); got != tt.wantBuckets {
+				t.Errorf("buckets %d, want %d", got, tt.wantBuckets)
+			}
+		})
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      string
+	}{
+		{name: "anonymous", want: "ip:192.0.2.1"},
+		{
+			name:      "api token",
+			principal: &auth.Principal{Method: auth.MethodAPIToken, TokenID: "t1", UserID: "u1"},
+			want:      "token:t1",
+		},
+		{
+			name:      "jwt uses the user",
+			principal: &auth.Principal{Method: auth.MethodJWT, TokenID: "jti-1", UserID: "u1"},
+			want:      "user:u1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users/getReview", nil)
+			r.RemoteAddr = "192.0.2.1:54321"
+			if tt.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(context.Background(), tt.principal))
+			}
+			if got := clientKey(r); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := IPRateLimitMiddleware(NewRateLimiter(1, nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	codes := make([]int, 0, 2)
+	for range 2 {
+		r := httptest.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+		r.RemoteAddr = "192.0.2.1:54321"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		codes = append(codes, w.Code)
+
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Error("429 without Retry-After")
+		}
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("got %v, want [200 429]", codes)
+	}
+}
+
+func repeat(d time.Duration, n int) []time.Duration {
+	result := make([]time.Duration, n)
+	for i := range result {
+		result[i] = d
+	}
+	return result
+}
+
+func repeatBool(b bool, n int) []bool {
+	result := make([]bool, n)
+	for i := range result {
+		result[i] = b
+	}
+	return result
+}
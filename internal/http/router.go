@@ -1,36 +1,88 @@
 package http
 
 import (
+	"AvitoTech/internal/domain/auth"
 	"AvitoTech/internal/http/handlers"
-	"log"
+	"AvitoTech/internal/metrics"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func NewRouter(teamHandler *handlers.TeamHandler, userHandler *handlers.UserHandler, prHandler *handlers.PRHandler) *chi.Mux {
+func NewRouter(
+	teamHandler *handlers.TeamHandler,
+	userHandler *handlers.UserHandler,
+	prHandler *handlers.PRHandler,
+	adminHandler *handlers.AdminHandler,
+	healthHandler *handlers.HealthHandler,
+	tokenHandler *handlers.TokenHandler,
+	ipRateLimit func(http.Handler) http.Handler,
+	authenticate func(http.Handler) http.Handler,
+	rateLimit func(http.Handler) http.Handler,
+	idempotent func(http.Handler) http.Handler,
+	teamScope TeamAuthorizer,
+) *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(RequestIDMiddleware, TracingMiddleware, AccessLogMiddleware, MetricsMiddleware)
 
-	r.Route("/team", func(r chi.Router) {
-		r.Post("/add", teamHandler.CreateTeam)
-		r.Get("/get", teamHandler.GetTeam)
-	})
+	r.Handle("/metrics", metrics.Handler())
 
-	r.Route("/users", func(r chi.Router) {
-		r.Post("/setIsActive", userHandler.SetUserActive)
-		r.Get("/getReview", userHandler.GetUserReviews)
+	r.Route("/health", func(r chi.Router) {
+		r.Get("/live", healthHandler.Live)
+		r.Get("/ready", healthHandler.Ready)
 	})
 
-	r.Route("/pullRequest", func(r chi.Router) {
-		r.Post("/create", prHandler.CreatePR)
-		r.Post("/merge", prHandler.MergePR)
-		r.Post("/reassign", prHandler.ReassignPR)
+	// пользовательским токенам доступны только свои ревью, создание PR,
+	// вердикты и отказы от ревью от своего имени (это проверяют обработчики)
+	// и смена статуса и состава ревьюверов своих PR; руководителям команд -
+	// управление своей командой (RequireTeamScope); остальное - только админам
+	admin := RequireRole(auth.RoleAdmin)
+	member := RequireRole(auth.RoleAdmin, auth.RoleUser)
+	lead := RequireTeamScope
+
+	r.Group(func(r chi.Router) {
+		// лимит по IP стоит до аутентификации: иначе запросы с неверными
+		// токенами получали бы 401, не расходуя лимит
+		r.Use(ipRateLimit, authenticate, rateLimit, idempotent)
+
+		r.Route("/team", func(r chi.Router) {
+			r.With(admin).Post("/add", teamHandler.CreateTeam)
+			r.With(admin).Get("/get", teamHandler.GetTeam)
+			r.With(lead(TeamFromQuery(teamScope))).Get("/settings", teamHandler.GetTeamSettings)
+			r.With(lead(TeamFromBody(teamScope))).Put("/settings", teamHandler.UpdateTeamSettings)
+			r.With(lead(TeamFromBody(teamScope))).Post("/deactivate", teamHandler.DeactivateTeam)
+			r.With(lead(TeamFromQuery(teamScope))).Get("/leads", teamHandler.GetTeamLeads)
+			r.With(admin).Put("/leads", teamHandler.SetTeamLeads)
+		})
+
+		r.Route("/users", func(r chi.Router) {
+			r.With(lead(UserFromBody(teamScope))).Post("/setIsActive", userHandler.SetUserActive)
+			r.With(lead(UserFromBody(teamScope))).Post("/setMaxOpenReviews", userHandler.SetMaxOpenReviews)
+			r.With(member).Get("/getReview", userHandler.GetUserReviews)
+		})
+
+		r.Route("/pullRequest", func(r chi.Router) {
+			r.With(member).Post("/create", prHandler.CreatePR)
+			r.With(admin).Post("/merge", prHandler.MergePR)
+			r.With(member).Post("/review", prHandler.ReviewPR)
+			r.With(member).Post("/decline", prHandler.DeclineReview)
+			r.With(lead(PRFromBody(teamScope))).Post("/reassign", prHandler.ReassignPR)
+			r.With(lead(PRAuthorFromBody(teamScope))).Post("/ready", prHandler.ReadyPR)
+			r.With(lead(PRAuthorFromBody(teamScope))).Post("/close", prHandler.ClosePR)
+			r.With(lead(PRAuthorFromBody(teamScope))).Post("/reopen", prHandler.ReopenPR)
+			r.With(lead(PRAuthorFromBody(teamScope))).Post("/reviewers/add", prHandler.AddReviewer)
+			r.With(lead(PRAuthorFromBody(teamScope))).Post("/reviewers/remove", prHandler.RemoveReviewer)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(admin)
+			r.Get("/db/pool", adminHandler.GetPoolStats)
+			r.Get("/config", adminHandler.GetConfig)
+			r.Get("/tokens", tokenHandler.ListTokens)
+			r.Post("/tokens", tokenHandler.CreateToken)
+			r.Post("/tokens/revoke", tokenHandler.RevokeToken)
+		})
 	})
 
 	return r
 }
-
-func StartServer(router *chi.Mux, addr string) error {
-	log.Printf("Сервер запущен на %s", addr)
-	return http.ListenAndServe(addr, router)
-}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"AvitoTech/pkg/logger"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+}
+
+func NewServer(handler http.Handler, cfg ServerConfig) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// StartServer блокируется до остановки сервера. Штатная остановка через
+// Shutdown не считается ошибкой
+func StartServer(server *http.Server) error {
+	logger.Log.Info("Сервер запущен", zap.String("addr", server.Addr))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ShutdownServer перестаёт принимать новые соединения и ждёт завершения
+// обрабатываемых запросов не дольше timeout
+func ShutdownServer(server *http.Server, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		// не дождались - обрываем оставшиеся соединения
+		_ = server.Close()
+		return err
+	}
+	return nil
+}
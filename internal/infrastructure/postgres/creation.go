@@ -1,73 +1,230 @@
 package postgres
 
 import (
+	"AvitoTech/internal/config"
+	"AvitoTech/internal/domain/dto"
 	"AvitoTech/pkg/logger"
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"math/rand"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
+const pingTimeout = 2 * time.Second
+
 type Postgres struct {
-	conn *pgx.Conn
+	pool *pgxpool.Pool
+
+	ready   atomic.Bool
+	lastErr atomic.Value
+
+	pingInterval time.Duration
+	stopMonitor  context.CancelFunc
+	monitorDone  sync.WaitGroup
 }
 
-func (db *Postgres) createConnectPath() (string, error) {
-	var dbParam [5]string
-	for i, param := range []string{"DB_USER", "DB_PASSWORD", "DB_HOST", "DB_PORT", "DB_NAME"} {
-		value := os.Getenv(param)
-		if value == "" {
-			return "", fmt.Errorf("environment variable %s is not set", param)
-		}
-		dbParam[i] = value
+func createConnectPath(cfg config.DBConfig) string {
+	dbURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     cfg.Name,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
 	}
+	return dbURL.String()
+}
 
-	dbURL := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		dbParam[0], // DB_USER
-		dbParam[1], // DB_PASSWORD
-		dbParam[2], // DB_HOST
-		dbParam[3], // DB_PORT
-		dbParam[4], // DB_NAME
-	)
+func createPoolConfig(cfg config.DBConfig) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(createConnectPath(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора строки подключения: %w", err)
+	}
+
+	poolCfg.MaxConns = int32(cfg.MaxConns)
+	poolCfg.MinConns = int32(cfg.MinConns)
+	poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	poolCfg.ConnConfig.Tracer = queryTracer{}
 
-	return dbURL, nil
+	return poolCfg, nil
 }
 
-func NewDB() (*Postgres, error) {
-	db := &Postgres{}
-	path, err := db.createConnectPath()
+// NewDB создаёт пул и ждёт доступности PostgreSQL, повторяя попытки с
+// экспоненциальной задержкой до истечения cfg.ConnectTimeout. При ошибке
+// пул уже закрыт и возвращается nil
+func NewDB(cfg config.DBConfig) (*Postgres, error) {
+	db := &Postgres{pingInterval: cfg.PingInterval}
+	poolCfg, err := createPoolConfig(cfg)
 	if err != nil {
-		return db, err
+		return nil, err
 	}
 
 	logger.Log.Info("Подключение к PostgreSQL...",
-		zap.String("host", os.Getenv("DB_HOST")),
-		zap.String("port", os.Getenv("DB_PORT")),
-		zap.String("database", os.Getenv("DB_NAME")),
+		zap.String("host", cfg.Host),
+		zap.Int("port", cfg.Port),
+		zap.String("database", cfg.Name),
+		zap.Int32("max_conns", poolCfg.MaxConns),
+		zap.Int32("min_conns", poolCfg.MinConns),
+		zap.Duration("connect_timeout", cfg.ConnectTimeout),
 	)
 
-	time.Sleep(5 * time.Second)
-
-	conn, err := pgx.Connect(context.Background(), path)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		logger.Log.Error("Ошибка подключения к БД", zap.Error(err))
-		return db, fmt.Errorf("ошибка подключения к базе данных: %w", err)
+		return nil, fmt.Errorf("ошибка подключения к базе данных: %w", err)
+	}
+	db.pool = pool
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	if err := db.waitReady(ctx, cfg.InitialBackoff, cfg.MaxBackoff); err != nil {
+		pool.Close()
+		logger.Log.Error("Ошибка подключения к БД", zap.Error(err))
+		return nil, fmt.Errorf("ошибка подключения к базе данных: %w", err)
 	}
-	db.conn = conn
 
 	logger.Log.Info("Успешное подключение к PostgreSQL")
 
 	return db, nil
 }
 
+func (db *Postgres) waitReady(ctx context.Context, initialBackoff, maxBackoff time.Duration) error {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.ping(ctx)
+		if err == nil {
+			db.setReady(nil)
+			return nil
+		}
+		db.setReady(err)
+
+		// половина задержки случайная, чтобы экземпляры не ломились в БД одновременно
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		logger.Log.Warn("PostgreSQL недоступен, повторная попытка",
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("база данных недоступна после %d попыток: %w", attempt, errors.Join(ctx.Err(), err))
+		case <-time.After(delay):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (db *Postgres) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return db.pool.Ping(ctx)
+}
+
+func (db *Postgres) setReady(err error) {
+	if err != nil {
+		db.lastErr.Store(err.Error())
+	} else {
+		db.lastErr.Store("")
+	}
+	db.ready.Store(err == nil)
+}
+
+// StartMonitor периодически проверяет соединение с БД. При потере связи
+// сбрасывает пул, чтобы новые запросы открывали свежие соединения, и
+// переводит состояние в "не готов" до восстановления
+func (db *Postgres) StartMonitor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	db.stopMonitor = cancel
+
+	db.monitorDone.Add(1)
+	go func() {
+		defer db.monitorDone.Done()
+
+		ticker := time.NewTicker(db.pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := db.ping(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			wasReady := db.ready.Load()
+			db.setReady(err)
+
+			switch {
+			case err != nil && wasReady:
+				logger.Log.Error("Потеряно соединение с PostgreSQL", zap.Error(err))
+				db.pool.Reset()
+			case err != nil:
+				logger.Log.Warn("PostgreSQL всё ещё недоступен", zap.Error(err))
+			case !wasReady:
+				logger.Log.Info("Соединение с PostgreSQL восстановлено")
+			}
+		}
+	}()
+}
+
+// Ready сообщает, отвечала ли БД на последнюю проверку
+func (db *Postgres) Ready() bool {
+	return db.ready.Load()
+}
+
+func (db *Postgres) LastError() string {
+	if v, ok := db.lastErr.Load().(string); ok {
+		return v
+	}
+	return ""
+}
+
 func (db *Postgres) CloseDB() error {
-	return db.conn.Close(context.Background())
+	if db.stopMonitor != nil {
+		db.stopMonitor()
+		db.monitorDone.Wait()
+	}
+	db.pool.Close()
+	return nil
 }
 
-func (db *Postgres) GetConn() *pgx.Conn {
-	return db.conn
+func (db *Postgres) GetPool() *pgxpool.Pool {
+	return db.pool
+}
+
+func (db *Postgres) PoolStats() dto.PoolStatsDTO {
+	stat := db.pool.Stat()
+	return dto.PoolStatsDTO{
+		MaxConns:                stat.MaxConns(),
+		TotalConns:              stat.TotalConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDurationMs:       stat.AcquireDuration().Milliseconds(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}
 }
@@ -0,0 +1,16 @@
+package postgres
+
+import (
+	"context"
+)
+
+const serverVersionQuery = `SHOW server_version`
+
+// CheckConnection проверяет, что БД отвечает, и возвращает версию сервера
+func (db *Postgres) CheckConnection(ctx context.Context) (string, error) {
+	var version string
+	if err := db.pool.QueryRow(ctx, serverVersionQuery).Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	reserveIdempotencyKeyQuery = `
+		INSERT INTO idempotency_keys (scope, idempotency_key, method, path, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP + make_interval(secs => $6))
+		ON CONFLICT (scope, idempotency_key) DO UPDATE
+		SET method = EXCLUDED.method,
+			path = EXCLUDED.path,
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP
+			OR (idempotency_keys.status_code IS NULL
+				AND idempotency_keys.created_at < CURRENT_TIMESTAMP - make_interval(secs => $7))
+		RETURNING 1
+	`
+	getIdempotencyKeyQuery = `
+		SELECT method, path, request_hash, COALESCE(status_code, 0), COALESCE(content_type, ''), response_body
+		FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2 AND expires_at > CURRENT_TIMESTAMP
+	`
+	saveIdempotentResponseQuery = `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_body = $5
+		WHERE scope = $1 AND idempotency_key = $2
+	`
+	deleteIdempotencyKeyQuery         = `DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2`
+	deleteExpiredIdempotencyKeysQuery = `DELETE FROM idempotency_keys WHERE expires_at <= CURRENT_TIMESTAMP`
+)
+
+type IdempotencyRepo struct {
+	db querier
+}
+
+func NewIdempotencyRepo(db *Postgres) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db.pool}
+}
+
+func (r *IdempotencyRepo) Reserve(ctx context.Context, record dto.IdempotencyRecordDTO, ttl, lockTimeout time.Duration) (bool, error) {
+	defer metrics.ObserveDBQuery("idempotency", "Reserve", time.Now())
+
+	var one int
+	err := conn(ctx, r.db).QueryRow(ctx, reserveIdempotencyKeyQuery,
+		record.Scope, record.Key, record.Method, record.Path, record.RequestHash,
+		ttl.Seconds(), lockTimeout.Seconds(),
+	).Scan(&one)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка при резервировании ключа идемпотентности: %v", err)
+	}
+	return true, nil
+}
+
+func (r *IdempotencyRepo) Get(ctx context.Context, scope, key string) (*dto.IdempotencyRecordDTO, error) {
+	defer metrics.ObserveDBQuery("idempotency", "Get", time.Now())
+
+	record := dto.IdempotencyRecordDTO{Scope: scope, Key: key}
+	err := conn(ctx, r.db).QueryRow(ctx, getIdempotencyKeyQuery, scope, key).Scan(
+		&record.Method,
+		&record.Path,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.Body,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка при получении ключа идемпотентности: %v", err)
+	}
+	return &record, nil
+}
+
+func (r *IdempotencyRepo) SaveResponse(ctx context.Context, record dto.IdempotencyRecordDTO) error {
+	defer metrics.ObserveDBQuery("idempotency", "SaveResponse", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, saveIdempotentResponseQuery,
+		record.Scope, record.Key, record.StatusCode, record.ContentType, record.Body,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении ответа по ключу идемпотентности: %v", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) Delete(ctx context.Context, scope, key string) error {
+	defer metrics.ObserveDBQuery("idempotency", "Delete", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, deleteIdempotencyKeyQuery, scope, key)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении ключа идемпотентности: %v", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	defer metrics.ObserveDBQuery("idempotency", "DeleteExpired", time.Now())
+
+	tag, err := conn(ctx, r.db).Exec(ctx, deleteExpiredIdempotencyKeysQuery)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при удалении истёкших ключей идемпотентности: %v", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,278 @@
+package postgres
+
+import (
+	"AvitoTech/pkg/logger"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID - ключ advisory-блокировки, под которой выполняются
+// миграции, чтобы одновременно запущенные экземпляры не применяли их дважды
+const migrationLockID = 7_301_845_926
+
+const (
+	createMigrationsTableQuery = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+	appliedVersionsQuery  = `SELECT version FROM schema_migrations ORDER BY version`
+	currentVersionQuery   = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	insertMigrationQuery  = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	deleteMigrationQuery  = `DELETE FROM schema_migrations WHERE version = $1`
+	migrationLockQuery    = `SELECT pg_advisory_lock($1)`
+	migrationUnlockQuery  = `SELECT pg_advisory_unlock($1)`
+	migrationsTableExists = `SELECT to_regclass('schema_migrations') IS NOT NULL`
+)
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+	down    string
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []migration
+}
+
+func NewMigrator(db *Postgres) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: db.pool, migrations: migrations}, nil
+}
+
+// loadMigrations читает пары файлов NNNN_name.up.sql / NNNN_name.down.sql
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*migration)
+	for _, file := range files {
+		base := path.Base(file)
+
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql suffix", base)
+		}
+
+		stem := strings.TrimSuffix(base, "."+direction+".sql")
+		rawVersion, name, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name format", base)
+		}
+		version, err := strconv.ParseInt(rawVersion, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", base, rawVersion)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if m.name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.name, name)
+		}
+		if direction == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
+
+// LatestVersion - версия последней встроенной миграции
+func (m *Migrator) LatestVersion() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].version
+}
+
+// Version возвращает последнюю применённую версию схемы (0 - ничего не применено)
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var exists bool
+	if err := m.pool.QueryRow(ctx, migrationsTableExists).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("ошибка при проверке таблицы миграций: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int64
+	if err := m.pool.QueryRow(ctx, currentVersionQuery).Scan(&version); err != nil {
+		return 0, fmt.Errorf("ошибка при получении версии схемы: %w", err)
+	}
+	return version, nil
+}
+
+// Up применяет все ещё не применённые миграции по возрастанию версии
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if applied[mig.version] {
+				continue
+			}
+
+			logger.FromContext(ctx).Info("Применение миграции",
+				zap.Int64("version", mig.version),
+				zap.String("name", mig.name),
+			)
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, insertMigrationQuery, mig.version, mig.name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("ошибка применения миграции %d_%s: %w", mig.version, mig.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down откатывает steps последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+
+	return m.withLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mig := m.migrations[i]
+			if !applied[mig.version] {
+				continue
+			}
+			if mig.down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", mig.version, mig.name)
+			}
+
+			logger.FromContext(ctx).Info("Откат миграции",
+				zap.Int64("version", mig.version),
+				zap.String("name", mig.name),
+			)
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, deleteMigrationQuery, mig.version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("ошибка отката миграции %d_%s: %w", mig.version, mig.name, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении соединения для миграций: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, migrationLockQuery, migrationLockID); err != nil {
+		return fmt.Errorf("ошибка при блокировке миграций: %w", err)
+	}
+	defer func() {
+		// контекст мог быть отменён, а блокировку нужно снять в любом случае
+		_, _ = conn.Exec(context.Background(), migrationUnlockQuery, migrationLockID)
+	}()
+
+	if _, err := conn.Exec(ctx, createMigrationsTableQuery); err != nil {
+		return fmt.Errorf("ошибка при создании таблицы миграций: %w", err)
+	}
+
+	return fn(conn.Conn())
+}
+
+func appliedVersions(ctx context.Context, conn *pgx.Conn) (map[int64]bool, error) {
+	rows, err := conn.Query(ctx, appliedVersionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении применённых миграций: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении версии миграции: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// CheckMigrations проверяет, что схема БД не отстаёт от встроенных миграций
+func (m *Migrator) CheckMigrations(ctx context.Context) (string, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	info := fmt.Sprintf("version %d", version)
+	if latest := m.LatestVersion(); version < latest {
+		return info, fmt.Errorf("schema version %d is behind latest migration %d", version, latest)
+	}
+	return info, nil
+}
@@ -31,4 +31,4 @@ CREATE TABLE IF NOT EXISTS pr_reviewers (
 CREATE INDEX IF NOT EXISTS idx_pr_reviewers_pr_id ON pr_reviewers(pull_request_id);
 CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer_id ON pr_reviewers(reviewer_id);
 CREATE INDEX IF NOT EXISTS idx_pull_requests_author_id ON pull_requests(author_id);
-CREATE INDEX IF NOT EXISTS idx_pull_requests_status ON pull_requests(status);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_pull_requests_status ON pull_requests(status);
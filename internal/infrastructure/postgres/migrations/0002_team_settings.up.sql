@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS team_settings (
+    team_name VARCHAR(255) PRIMARY KEY REFERENCES teams(team_name) ON DELETE CASCADE,
+    required_reviewers INTEGER NOT NULL DEFAULT 2 CHECK (required_reviewers >= 0),
+    min_reviewers INTEGER NOT NULL DEFAULT 0 CHECK (min_reviewers >= 0 AND min_reviewers <= required_reviewers),
+    strategy VARCHAR(32) NOT NULL DEFAULT '',
+    allow_cross_team_fallback BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,9 @@
+ALTER TABLE pr_reviewers
+    ADD COLUMN IF NOT EXISTS verdict VARCHAR(32)
+        CHECK (verdict IN ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED')),
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+ALTER TABLE team_settings
+    ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 1 CHECK (required_approvals >= 0);
+-- команды, которым ревьюверы не назначаются, не должны упереться в недостижимый порог
+UPDATE team_settings SET required_approvals = LEAST(required_approvals, required_reviewers);
@@ -0,0 +1,3 @@
+ALTER TABLE team_settings
+    ADD COLUMN IF NOT EXISTS max_reviewers INTEGER NOT NULL DEFAULT 5 CHECK (max_reviewers >= 0);
+UPDATE team_settings SET max_reviewers = GREATEST(max_reviewers, required_reviewers);
@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS understaffed;
+ALTER TABLE users DROP COLUMN IF EXISTS max_open_reviews;
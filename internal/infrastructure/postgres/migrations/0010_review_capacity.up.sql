@@ -0,0 +1,3 @@
+-- NULL - действует глобальный лимит reviewers.max_open_reviews, 0 - без лимита
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_open_reviews INTEGER CHECK (max_open_reviews >= 0);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS understaffed BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,49 @@
+package postgrestest
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeTxManager выполняет функцию без базы данных и запоминает, чем
+// закончилась каждая "транзакция". Предназначен для тестов доменных сервисов
+type FakeTxManager struct {
+	mu sync.Mutex
+
+	// BeginErr, если задан, возвращается вместо вызова функции
+	BeginErr error
+
+	Begun      int
+	Committed  int
+	RolledBack int
+}
+
+func NewFakeTxManager() *FakeTxManager {
+	return &FakeTxManager{}
+}
+
+func (m *FakeTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	m.mu.Lock()
+	if m.BeginErr != nil {
+		m.mu.Unlock()
+		return m.BeginErr
+	}
+	m.Begun++
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if p := recover(); p != nil {
+			m.RolledBack++
+			panic(p)
+		}
+		if err != nil {
+			m.RolledBack++
+			return
+		}
+		m.Committed++
+	}()
+
+	return fn(ctx)
+}
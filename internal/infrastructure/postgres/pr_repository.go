@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
 	"context"
 	"errors"
 	"fmt"
@@ -19,20 +20,26 @@ const (
 	`
 
 	getPRQuery = `
-		SELECT pull_request_id, pull_request_name, author_id, status
+		SELECT pull_request_id, pull_request_name, author_id, status, understaffed
 		FROM pull_requests
 		WHERE pull_request_id = $1
 	`
 
-	updatePRStatusQuery = `
+	transitionStatusQuery = `
 		UPDATE pull_requests
-		SET status = $2
-		WHERE pull_request_id = $1
+		SET status = $3::varchar,
+			merged_at = CASE WHEN $3::varchar = 'MERGED' THEN $4::timestamp ELSE merged_at END,
+			closed_at = CASE
+				WHEN $3::varchar = 'CLOSED' THEN $4::timestamp
+				WHEN $3::varchar = 'OPEN' THEN NULL
+				ELSE closed_at
+			END
+		WHERE pull_request_id = $1 AND status = $2
 	`
 
-	setMergedAtQuery = `
+	setUnderstaffedQuery = `
 		UPDATE pull_requests
-		SET merged_at = $2
+		SET understaffed = $2
 		WHERE pull_request_id = $1
 	`
 
@@ -59,27 +66,96 @@ const (
 			WHERE pull_request_id = $1 AND reviewer_id = $2
 		)
 	`
+
+	// COMMENTED не отменяет ранее принятое решение ревьювера
+	setReviewVerdictQuery = `
+		UPDATE pr_reviewers
+		SET verdict = CASE
+				WHEN $3::varchar = 'COMMENTED' AND verdict IS NOT NULL THEN verdict
+				ELSE $3::varchar
+			END,
+			reviewed_at = $4
+		WHERE pull_request_id = $1 AND reviewer_id = $2
+	`
+
+	resetReviewVerdictsQuery = `
+		UPDATE pr_reviewers
+		SET verdict = NULL, reviewed_at = NULL
+		WHERE pull_request_id = $1
+	`
+
+	getReviewsQuery = `
+		SELECT reviewer_id, COALESCE(verdict, ''), reviewed_at
+		FROM pr_reviewers
+		WHERE pull_request_id = $1
+		ORDER BY assigned_at
+	`
+
+	recordDeclineQuery = `
+		INSERT INTO pr_reviewer_declines (pull_request_id, reviewer_id, reason, declined_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (pull_request_id, reviewer_id) DO UPDATE
+		SET reason = EXCLUDED.reason,
+			declined_at = EXCLUDED.declined_at
+	`
+
+	getDeclinedReviewersQuery = `
+		SELECT reviewer_id
+		FROM pr_reviewer_declines
+		WHERE pull_request_id = $1
+		ORDER BY declined_at
+	`
+
+	getOpenReviewCountsQuery = `
+		SELECT prr.reviewer_id, COUNT(*)
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		WHERE prr.reviewer_id = ANY($1) AND pr.status = $2
+		GROUP BY prr.reviewer_id
+	`
+
+	getOpenReviewsByReviewersQuery = `
+		SELECT pr.pull_request_id, pr.author_id, COALESCE(a.team_name, ''), prr.reviewer_id,
+			ARRAY(
+				SELECT r.reviewer_id FROM pr_reviewers r
+				WHERE r.pull_request_id = pr.pull_request_id
+				ORDER BY r.assigned_at
+			),
+			ARRAY(
+				SELECT d.reviewer_id FROM pr_reviewer_declines d
+				WHERE d.pull_request_id = pr.pull_request_id
+			)
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+		LEFT JOIN users a ON a.user_id = pr.author_id
+		WHERE prr.reviewer_id = ANY($1) AND pr.status = $2
+		ORDER BY pr.created_at, pr.pull_request_id
+	`
 )
 
 type PRRepo struct {
-	db *pgx.Conn
+	db querier
 }
 
 func NewPRRepo(db *Postgres) *PRRepo {
-	return &PRRepo{db: db.conn}
+	return &PRRepo{db: db.pool}
 }
 
 func (r *PRRepo) PRExists(ctx context.Context, prID string) (bool, error) {
+	defer metrics.ObserveDBQuery("pr", "PRExists", time.Now())
+
 	var exists bool
-	err := r.db.QueryRow(ctx, prExistsQuery, prID).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, prExistsQuery, prID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("ошибка при проверке существования PR: %v", err)
 	}
 	return exists, nil
 }
 
-func (r *PRRepo) CreatePR(ctx context.Context, prID, prName, authorID string) error {
-	_, err := r.db.Exec(ctx, createPRQuery, prID, prName, authorID, dto.StatusOpen, time.Now())
+func (r *PRRepo) CreatePR(ctx context.Context, prID, prName, authorID, status string) error {
+	defer metrics.ObserveDBQuery("pr", "CreatePR", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, createPRQuery, prID, prName, authorID, status, time.Now())
 	if err != nil {
 		return fmt.Errorf("ошибка при создании PR: %v", err)
 	}
@@ -87,12 +163,15 @@ func (r *PRRepo) CreatePR(ctx context.Context, prID, prName, authorID string) er
 }
 
 func (r *PRRepo) GetPR(ctx context.Context, prID string) (*dto.PullRequestDTO, error) {
+	defer metrics.ObserveDBQuery("pr", "GetPR", time.Now())
+
 	var pr dto.PullRequestDTO
-	err := r.db.QueryRow(ctx, getPRQuery, prID).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, getPRQuery, prID).Scan(
 		&pr.PullRequestID,
 		&pr.PullRequestName,
 		&pr.AuthorID,
 		&pr.Status,
+		&pr.Understaffed,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -110,24 +189,32 @@ func (r *PRRepo) GetPR(ctx context.Context, prID string) (*dto.PullRequestDTO, e
 	return &pr, nil
 }
 
-func (r *PRRepo) UpdatePRStatus(ctx context.Context, prID, status string) error {
-	_, err := r.db.Exec(ctx, updatePRStatusQuery, prID, status)
+// TransitionStatus меняет статус, только если PR всё ещё в статусе from, и
+// проставляет merged_at/closed_at. false - статус успели изменить
+func (r *PRRepo) TransitionStatus(ctx context.Context, prID, from, to string) (bool, error) {
+	defer metrics.ObserveDBQuery("pr", "TransitionStatus", time.Now())
+
+	tag, err := conn(ctx, r.db).Exec(ctx, transitionStatusQuery, prID, from, to, time.Now())
 	if err != nil {
-		return fmt.Errorf("ошибка при обновлении статуса PR: %v", err)
+		return false, fmt.Errorf("ошибка при обновлении статуса PR: %v", err)
 	}
-	return nil
+	return tag.RowsAffected() > 0, nil
 }
 
-func (r *PRRepo) SetMergedAt(ctx context.Context, prID string) error {
-	_, err := r.db.Exec(ctx, setMergedAtQuery, prID, time.Now())
+func (r *PRRepo) SetUnderstaffed(ctx context.Context, prID string, understaffed bool) error {
+	defer metrics.ObserveDBQuery("pr", "SetUnderstaffed", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, setUnderstaffedQuery, prID, understaffed)
 	if err != nil {
-		return fmt.Errorf("ошибка при установке времени мерджа: %v", err)
+		return fmt.Errorf("ошибка при обновлении признака нехватки ревьюверов: %v", err)
 	}
 	return nil
 }
 
 func (r *PRRepo) GetReviewers(ctx context.Context, prID string) ([]string, error) {
-	rows, err := r.db.Query(ctx, getReviewersQuery, prID)
+	defer metrics.ObserveDBQuery("pr", "GetReviewers", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getReviewersQuery, prID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении ревьюверов: %v", err)
 	}
@@ -146,6 +233,8 @@ func (r *PRRepo) GetReviewers(ctx context.Context, prID string) ([]string, error
 }
 
 func (r *PRRepo) AssignReviewers(ctx context.Context, prID string, reviewerIDs []string) error {
+	defer metrics.ObserveDBQuery("pr", "AssignReviewers", time.Now())
+
 	for _, reviewerID := range reviewerIDs {
 		if err := r.AddReviewer(ctx, prID, reviewerID); err != nil {
 			return err
@@ -154,16 +243,20 @@ func (r *PRRepo) AssignReviewers(ctx context.Context, prID string, reviewerIDs [
 	return nil
 }
 
-func (r *PRRepo) RemoveReviewer(ctx context.Context, prID, reviewerID string) error {
-	_, err := r.db.Exec(ctx, removeReviewerQuery, prID, reviewerID)
+func (r *PRRepo) RemoveReviewer(ctx context.Context, prID, reviewerID string) (bool, error) {
+	defer metrics.ObserveDBQuery("pr", "RemoveReviewer", time.Now())
+
+	tag, err := conn(ctx, r.db).Exec(ctx, removeReviewerQuery, prID, reviewerID)
 	if err != nil {
-		return fmt.Errorf("ошибка при удалении ревьювера: %v", err)
+		return false, fmt.Errorf("ошибка при удалении ревьювера: %v", err)
 	}
-	return nil
+	return tag.RowsAffected() > 0, nil
 }
 
 func (r *PRRepo) AddReviewer(ctx context.Context, prID, reviewerID string) error {
-	_, err := r.db.Exec(ctx, assignReviewerQuery, prID, reviewerID)
+	defer metrics.ObserveDBQuery("pr", "AddReviewer", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, assignReviewerQuery, prID, reviewerID)
 	if err != nil {
 		return fmt.Errorf("ошибка при назначении ревьювера: %v", err)
 	}
@@ -171,10 +264,138 @@ func (r *PRRepo) AddReviewer(ctx context.Context, prID, reviewerID string) error
 }
 
 func (r *PRRepo) IsReviewerAssigned(ctx context.Context, prID, reviewerID string) (bool, error) {
+	defer metrics.ObserveDBQuery("pr", "IsReviewerAssigned", time.Now())
+
 	var exists bool
-	err := r.db.QueryRow(ctx, isReviewerAssignedQuery, prID, reviewerID).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, isReviewerAssignedQuery, prID, reviewerID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("ошибка при проверке назначения ревьювера: %v", err)
 	}
 	return exists, nil
 }
+
+func (r *PRRepo) SetReviewVerdict(ctx context.Context, prID, reviewerID, verdict string) (bool, error) {
+	defer metrics.ObserveDBQuery("pr", "SetReviewVerdict", time.Now())
+
+	tag, err := conn(ctx, r.db).Exec(ctx, setReviewVerdictQuery, prID, reviewerID, verdict, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("ошибка при сохранении вердикта ревьювера: %v", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PRRepo) ResetReviewVerdicts(ctx context.Context, prID string) error {
+	defer metrics.ObserveDBQuery("pr", "ResetReviewVerdicts", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, resetReviewVerdictsQuery, prID)
+	if err != nil {
+		return fmt.Errorf("ошибка при сбросе вердиктов ревьюверов: %v", err)
+	}
+	return nil
+}
+
+func (r *PRRepo) GetReviews(ctx context.Context, prID string) ([]dto.ReviewDTO, error) {
+	defer metrics.ObserveDBQuery("pr", "GetReviews", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getReviewsQuery, prID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении вердиктов ревьюверов: %v", err)
+	}
+	defer rows.Close()
+
+	reviews := []dto.ReviewDTO{}
+	for rows.Next() {
+		var review dto.ReviewDTO
+		var reviewedAt *time.Time
+		if err := rows.Scan(&review.ReviewerID, &review.Verdict, &reviewedAt); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении вердикта ревьювера: %v", err)
+		}
+		if reviewedAt != nil {
+			review.ReviewedAt = reviewedAt.Format(time.RFC3339)
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, nil
+}
+
+func (r *PRRepo) RecordDecline(ctx context.Context, prID, reviewerID, reason string) error {
+	defer metrics.ObserveDBQuery("pr", "RecordDecline", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, recordDeclineQuery, prID, reviewerID, reason, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении отказа от ревью: %v", err)
+	}
+	return nil
+}
+
+func (r *PRRepo) GetDeclinedReviewers(ctx context.Context, prID string) ([]string, error) {
+	defer metrics.ObserveDBQuery("pr", "GetDeclinedReviewers", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getDeclinedReviewersQuery, prID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении отказов от ревью: %v", err)
+	}
+	defer rows.Close()
+
+	reviewerIDs := []string{}
+	for rows.Next() {
+		var reviewerID string
+		if err := rows.Scan(&reviewerID); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении отказа от ревью: %v", err)
+		}
+		reviewerIDs = append(reviewerIDs, reviewerID)
+	}
+
+	return reviewerIDs, nil
+}
+
+func (r *PRRepo) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	defer metrics.ObserveDBQuery("pr", "GetOpenReviewCounts", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getOpenReviewCountsQuery, userIDs, dto.StatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении нагрузки ревьюверов: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении нагрузки ревьювера: %v", err)
+		}
+		counts[userID] = count
+	}
+
+	return counts, nil
+}
+
+func (r *PRRepo) GetOpenReviewsByReviewers(ctx context.Context, reviewerIDs []string) ([]dto.OpenReviewDTO, error) {
+	defer metrics.ObserveDBQuery("pr", "GetOpenReviewsByReviewers", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getOpenReviewsByReviewersQuery, reviewerIDs, dto.StatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении открытых ревью: %v", err)
+	}
+	defer rows.Close()
+
+	reviews := []dto.OpenReviewDTO{}
+	for rows.Next() {
+		var review dto.OpenReviewDTO
+		if err := rows.Scan(
+			&review.PullRequestID,
+			&review.AuthorID,
+			&review.AuthorTeam,
+			&review.ReviewerID,
+			&review.Reviewers,
+			&review.Declined,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении открытого ревью: %v", err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, nil
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"AvitoTech/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	getTeamLeadsQuery = `
+		SELECT tl.user_id
+		FROM team_leads tl
+		JOIN users u ON u.user_id = tl.user_id AND u.team_name = tl.team_name
+		WHERE tl.team_name = $1
+		ORDER BY tl.user_id
+	`
+	deleteTeamLeadsQuery = `DELETE FROM team_leads WHERE team_name = $1`
+	insertTeamLeadsQuery = `
+		INSERT INTO team_leads (team_name, user_id)
+		SELECT $1, unnest($2::varchar[])
+		ON CONFLICT DO NOTHING
+	`
+	isTeamLeadQuery = `
+		SELECT EXISTS(
+			SELECT 1 FROM team_leads tl
+			JOIN users u ON u.user_id = tl.user_id AND u.team_name = tl.team_name
+			WHERE tl.team_name = $1 AND tl.user_id = $2
+		)
+	`
+	getUserTeamQuery = `SELECT COALESCE(team_name, '') FROM users WHERE user_id = $1`
+	getPRAuthorQuery = `
+		SELECT pr.author_id, COALESCE(u.team_name, '')
+		FROM pull_requests pr
+		JOIN users u ON u.user_id = pr.author_id
+		WHERE pr.pull_request_id = $1
+	`
+)
+
+type TeamLeadRepo struct {
+	db querier
+}
+
+func NewTeamLeadRepo(db *Postgres) *TeamLeadRepo {
+	return &TeamLeadRepo{db: db.pool}
+}
+
+func (r *TeamLeadRepo) GetTeamLeads(ctx context.Context, teamName string) ([]string, error) {
+	defer metrics.ObserveDBQuery("team_lead", "GetTeamLeads", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getTeamLeadsQuery, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении руководителей команды: %v", err)
+	}
+	defer rows.Close()
+
+	leads := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении руководителей команды: %v", err)
+		}
+		leads = append(leads, userID)
+	}
+	return leads, rows.Err()
+}
+
+// ReplaceTeamLeads заменяет список руководителей целиком. Атомарность
+// обеспечивает вызывающий через TxManager
+func (r *TeamLeadRepo) ReplaceTeamLeads(ctx context.Context, teamName string, userIDs []string) error {
+	defer metrics.ObserveDBQuery("team_lead", "ReplaceTeamLeads", time.Now())
+
+	db := conn(ctx, r.db)
+	if _, err := db.Exec(ctx, deleteTeamLeadsQuery, teamName); err != nil {
+		return fmt.Errorf("ошибка при удалении руководителей команды: %v", err)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	if _, err := db.Exec(ctx, insertTeamLeadsQuery, teamName, userIDs); err != nil {
+		return fmt.Errorf("ошибка при добавлении руководителей команды: %v", err)
+	}
+	return nil
+}
+
+func (r *TeamLeadRepo) IsTeamLead(ctx context.Context, teamName, userID string) (bool, error) {
+	defer metrics.ObserveDBQuery("team_lead", "IsTeamLead", time.Now())
+
+	var isLead bool
+	err := conn(ctx, r.db).QueryRow(ctx, isTeamLeadQuery, teamName, userID).Scan(&isLead)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке руководителя команды: %v", err)
+	}
+	return isLead, nil
+}
+
+func (r *TeamLeadRepo) GetUserTeam(ctx context.Context, userID string) (string, bool, error) {
+	defer metrics.ObserveDBQuery("team_lead", "GetUserTeam", time.Now())
+
+	var teamName string
+	err := conn(ctx, r.db).QueryRow(ctx, getUserTeamQuery, userID).Scan(&teamName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("ошибка при получении команды пользователя: %v", err)
+	}
+	return teamName, true, nil
+}
+
+func (r *TeamLeadRepo) GetPRAuthor(ctx context.Context, prID string) (string, string, bool, error) {
+	defer metrics.ObserveDBQuery("team_lead", "GetPRAuthor", time.Now())
+
+	var authorID, teamName string
+	err := conn(ctx, r.db).QueryRow(ctx, getPRAuthorQuery, prID).Scan(&authorID, &teamName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", false, nil
+		}
+		return "", "", false, fmt.Errorf("ошибка при получении автора PR: %v", err)
+	}
+	return authorID, teamName, true, nil
+}
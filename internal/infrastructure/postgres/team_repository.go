@@ -2,10 +2,10 @@ package postgres
 
 import (
 	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
 	"context"
 	"fmt"
-
-	"github.com/jackc/pgx/v5"
+	"time"
 )
 
 const (
@@ -21,19 +21,47 @@ const (
       team_name = EXCLUDED.team_name,
       is_active = EXCLUDED.is_active
  `
+	lockTeamQuery        = `SELECT pg_advisory_xact_lock(hashtext('team:' || $1))`
+	tryLockTeamQuery     = `SELECT pg_try_advisory_xact_lock(hashtext('team:' || $1))`
+	getTeamSettingsQuery = `
+		SELECT t.team_name,
+			COALESCE(s.required_reviewers, $2),
+			COALESCE(s.min_reviewers, $3),
+			COALESCE(s.required_approvals, $4),
+			COALESCE(s.max_reviewers, $5),
+			COALESCE(s.strategy, ''),
+			COALESCE(s.allow_cross_team_fallback, false)
+		FROM teams t
+		LEFT JOIN team_settings s ON s.team_name = t.team_name
+		WHERE t.team_name = $1
+	`
+	upsertTeamSettingsQuery = `
+		INSERT INTO team_settings (team_name, required_reviewers, min_reviewers, required_approvals, max_reviewers, strategy, allow_cross_team_fallback, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+		ON CONFLICT (team_name) DO UPDATE
+		SET required_reviewers = EXCLUDED.required_reviewers,
+			min_reviewers = EXCLUDED.min_reviewers,
+			required_approvals = EXCLUDED.required_approvals,
+			max_reviewers = EXCLUDED.max_reviewers,
+			strategy = EXCLUDED.strategy,
+			allow_cross_team_fallback = EXCLUDED.allow_cross_team_fallback,
+			updated_at = EXCLUDED.updated_at
+	`
 )
 
 type TeamRepo struct {
-	db *pgx.Conn
+	db querier
 }
 
 func NewTeamRepo(db *Postgres) *TeamRepo {
-	return &TeamRepo{db: db.conn}
+	return &TeamRepo{db: db.pool}
 }
 
 func (r *TeamRepo) TeamExists(ctx context.Context, name string) (bool, error) {
+	defer metrics.ObserveDBQuery("team", "TeamExists", time.Now())
+
 	var exists bool
-	err := r.db.QueryRow(ctx, teamExistQuery, name).Scan(&exists)
+	err := conn(ctx, r.db).QueryRow(ctx, teamExistQuery, name).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("ошибка при проверке существования команды: %v", err)
 	}
@@ -41,13 +69,15 @@ func (r *TeamRepo) TeamExists(ctx context.Context, name string) (bool, error) {
 }
 
 func (r *TeamRepo) CreateTeam(ctx context.Context, team dto.TeamDTO) error {
-	_, err := r.db.Exec(ctx, createTeamQuery, team.TeamName)
+	defer metrics.ObserveDBQuery("team", "CreateTeam", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, createTeamQuery, team.TeamName)
 	if err != nil {
 		return fmt.Errorf("ошибка при создании команды: %v", err)
 	}
 
 	for _, member := range team.Members {
-		_, err := r.db.Exec(ctx, upsertUserQuery,
+		_, err := conn(ctx, r.db).Exec(ctx, upsertUserQuery,
 			member.UserID,
 			member.Username,
 			team.TeamName,
@@ -62,13 +92,15 @@ func (r *TeamRepo) CreateTeam(ctx context.Context, team dto.TeamDTO) error {
 }
 
 func (r *TeamRepo) GetTeam(ctx context.Context, name string) (dto.TeamDTO, error) {
+	defer metrics.ObserveDBQuery("team", "GetTeam", time.Now())
+
 	var teamName string
-	err := r.db.QueryRow(ctx, getTeamQuery, name).Scan(&teamName)
+	err := conn(ctx, r.db).QueryRow(ctx, getTeamQuery, name).Scan(&teamName)
 	if err != nil {
 		return dto.TeamDTO{}, fmt.Errorf("ошибка при получении команды: %v", err)
 	}
 
-	rows, err := r.db.Query(ctx, getUsersQuery, teamName)
+	rows, err := conn(ctx, r.db).Query(ctx, getUsersQuery, teamName)
 	if err != nil {
 		return dto.TeamDTO{}, fmt.Errorf("ошибка при получении участников команды: %v", err)
 	}
@@ -88,3 +120,66 @@ func (r *TeamRepo) GetTeam(ctx context.Context, name string) (dto.TeamDTO, error
 		Members:  members,
 	}, nil
 }
+
+func (r *TeamRepo) GetTeamSettings(ctx context.Context, name string) (*dto.TeamSettingsDTO, error) {
+	defer metrics.ObserveDBQuery("team", "GetTeamSettings", time.Now())
+
+	var settings dto.TeamSettingsDTO
+	err := conn(ctx, r.db).QueryRow(ctx, getTeamSettingsQuery, name, dto.DefaultRequiredReviewers, dto.DefaultMinReviewers, dto.DefaultRequiredApprovals, dto.DefaultMaxReviewers).Scan(
+		&settings.TeamName,
+		&settings.RequiredReviewers,
+		&settings.MinReviewers,
+		&settings.RequiredApprovals,
+		&settings.MaxReviewers,
+		&settings.Strategy,
+		&settings.AllowCrossTeamFallback,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении настроек команды: %v", err)
+	}
+	return &settings, nil
+}
+
+func (r *TeamRepo) UpsertTeamSettings(ctx context.Context, settings dto.TeamSettingsDTO) error {
+	defer metrics.ObserveDBQuery("team", "UpsertTeamSettings", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, upsertTeamSettingsQuery,
+		settings.TeamName,
+		settings.RequiredReviewers,
+		settings.MinReviewers,
+		settings.RequiredApprovals,
+		settings.MaxReviewers,
+		settings.Strategy,
+		settings.AllowCrossTeamFallback,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении настроек команды: %v", err)
+	}
+	return nil
+}
+
+// LockTeam берёт транзакционную advisory-блокировку команды, сериализуя
+// назначение ревьюверов между экземплярами сервиса. Вне транзакции
+// блокировка снимается сразу после запроса
+func (r *TeamRepo) LockTeam(ctx context.Context, name string) error {
+	defer metrics.ObserveDBQuery("team", "LockTeam", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, lockTeamQuery, name)
+	if err != nil {
+		return fmt.Errorf("ошибка при блокировке команды: %v", err)
+	}
+	return nil
+}
+
+// TryLockTeam берёт ту же блокировку без ожидания и возвращает false, если
+// её держит другая транзакция
+func (r *TeamRepo) TryLockTeam(ctx context.Context, name string) (bool, error) {
+	defer metrics.ObserveDBQuery("team", "TryLockTeam", time.Now())
+
+	var locked bool
+	err := conn(ctx, r.db).QueryRow(ctx, tryLockTeamQuery, name).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при блокировке команды: %v", err)
+	}
+	return locked, nil
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	createTokenQuery = `
+		INSERT INTO api_tokens (token_id, name, role, user_id, token_hash, prefix, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+		ON CONFLICT (token_hash) DO NOTHING
+		RETURNING created_at
+	`
+	getActiveTokenByHashQuery = `
+		SELECT token_id, name, role, COALESCE(user_id, ''), prefix, created_at, expires_at, last_used_at, revoked_at
+		FROM api_tokens
+		WHERE token_hash = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+	`
+	listTokensQuery = `
+		SELECT token_id, name, role, COALESCE(user_id, ''), prefix, created_at, expires_at, last_used_at, revoked_at
+		FROM api_tokens
+		ORDER BY created_at DESC
+	`
+	revokeTokenQuery = `UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE token_id = $1 AND revoked_at IS NULL`
+	// last_used_at обновляется не чаще раза в минуту, чтобы каждый запрос не писал в БД
+	touchTokenQuery = `
+		UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP
+		WHERE token_id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+	`
+)
+
+type TokenRepo struct {
+	db querier
+}
+
+func NewTokenRepo(db *Postgres) *TokenRepo {
+	return &TokenRepo{db: db.pool}
+}
+
+// CreateToken возвращает nil без ошибки, если токен с таким хешем уже есть
+func (r *TokenRepo) CreateToken(ctx context.Context, token dto.APITokenDTO, hash string, expiresAt *time.Time) (*dto.APITokenDTO, error) {
+	defer metrics.ObserveDBQuery("token", "CreateToken", time.Now())
+
+	var createdAt time.Time
+	err := conn(ctx, r.db).QueryRow(ctx, createTokenQuery,
+		token.TokenID, token.Name, token.Role, token.UserID, hash, token.Prefix, expiresAt,
+	).Scan(&createdAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка при создании токена: %v", err)
+	}
+
+	token.CreatedAt = formatTime(&createdAt)
+	token.ExpiresAt = formatTime(expiresAt)
+	return &token, nil
+}
+
+func (r *TokenRepo) GetActiveTokenByHash(ctx context.Context, hash string) (*dto.APITokenDTO, error) {
+	defer metrics.ObserveDBQuery("token", "GetActiveTokenByHash", time.Now())
+
+	token, err := scanToken(conn(ctx, r.db).QueryRow(ctx, getActiveTokenByHashQuery, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка при поиске токена: %v", err)
+	}
+	return token, nil
+}
+
+func (r *TokenRepo) ListTokens(ctx context.Context) ([]dto.APITokenDTO, error) {
+	defer metrics.ObserveDBQuery("token", "ListTokens", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, listTokensQuery)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении токенов: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []dto.APITokenDTO{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при чтении токена: %v", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при получении токенов: %v", err)
+	}
+	return tokens, nil
+}
+
+func (r *TokenRepo) RevokeToken(ctx context.Context, tokenID string) (bool, error) {
+	defer metrics.ObserveDBQuery("token", "RevokeToken", time.Now())
+
+	tag, err := conn(ctx, r.db).Exec(ctx, revokeTokenQuery, tokenID)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при отзыве токена: %v", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *TokenRepo) TouchToken(ctx context.Context, tokenID string) error {
+	defer metrics.ObserveDBQuery("token", "TouchToken", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, touchTokenQuery, tokenID)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении времени использования токена: %v", err)
+	}
+	return nil
+}
+
+func scanToken(row pgx.Row) (*dto.APITokenDTO, error) {
+	var (
+		token                          dto.APITokenDTO
+		createdAt                      time.Time
+		expiresAt, lastUsedAt, revoked *time.Time
+	)
+	err := row.Scan(
+		&token.TokenID,
+		&token.Name,
+		&token.Role,
+		&token.UserID,
+		&token.Prefix,
+		&createdAt,
+		&expiresAt,
+		&lastUsedAt,
+		&revoked,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.CreatedAt = formatTime(&createdAt)
+	token.ExpiresAt = formatTime(expiresAt)
+	token.LastUsedAt = formatTime(lastUsedAt)
+	token.RevokedAt = formatTime(revoked)
+	return &token, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
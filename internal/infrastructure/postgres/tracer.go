@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"AvitoTech/internal/tracing"
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxStatementLength = 2000
+
+// queryTracer создаёт спан на каждый SQL-запрос и пакет запросов pgx
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracing.Start(ctx, "db "+sqlOperation(data.SQL),
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", compactSQL(data.SQL)),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	tracing.Fail(span, data.Err)
+	span.End()
+}
+
+func (queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx, _ = tracing.Start(ctx, "db batch",
+		attribute.String("db.system", "postgresql"),
+		attribute.Int("db.batch.size", data.Batch.Len()),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("db "+sqlOperation(data.SQL), trace.WithAttributes(
+		attribute.String("db.statement", compactSQL(data.SQL)),
+	))
+	tracing.Fail(span, data.Err)
+}
+
+func (queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	span := trace.SpanFromContext(ctx)
+	tracing.Fail(span, data.Err)
+	span.End()
+}
+
+// sqlOperation - первое слово запроса (SELECT, INSERT, WITH...) для имени спана
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// compactSQL схлопывает отступы в запросах-константах и обрезает длинный текст
+func compactSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxStatementLength {
+		sql = sql[:maxStatementLength]
+	}
+	return sql
+}
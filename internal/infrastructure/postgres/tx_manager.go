@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"AvitoTech/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type txKey struct{}
+
+// conn возвращает транзакцию из контекста, если запрос выполняется внутри
+// TxManager.WithinTx, иначе - пул соединений
+func conn(ctx context.Context, db querier) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+type TxManager struct {
+	db *pgxpool.Pool
+}
+
+func NewTxManager(db *Postgres) *TxManager {
+	return &TxManager{db: db.pool}
+}
+
+// WithinTx выполняет fn в транзакции и фиксирует её, если fn не вернула ошибку.
+// При ошибке или панике транзакция откатывается. Вложенный вызов открывает
+// точку сохранения внутри внешней транзакции
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	var tx pgx.Tx
+	if outer, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		tx, err = outer.Begin(ctx)
+	} else {
+		tx, err = m.db.Begin(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии транзакции: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback(ctx, tx)
+			panic(p)
+		}
+		if err != nil {
+			rollback(ctx, tx)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func rollback(ctx context.Context, tx pgx.Tx) {
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		logger.FromContext(ctx).Warn("Ошибка при откате транзакции", zap.Error(err))
+	}
+}
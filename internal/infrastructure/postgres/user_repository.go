@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"AvitoTech/internal/domain/dto"
+	"AvitoTech/internal/metrics"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -17,7 +19,8 @@ const (
 		ORDER BY pr.created_at DESC
 	`
 	setUserActiveQuery      = `UPDATE users SET is_active = $2 WHERE user_id = $1`
-	getUserQuery            = `SELECT user_id, username, team_name, is_active FROM users WHERE user_id = $1`
+	getUserQuery            = `SELECT user_id, username, team_name, is_active, max_open_reviews FROM users WHERE user_id = $1`
+	setMaxOpenReviewsQuery  = `UPDATE users SET max_open_reviews = $2 WHERE user_id = $1`
 	createOrUpdateUserQuery = `
 		INSERT INTO users (user_id, username, team_name, is_active)
 		VALUES ($1, $2, $3, $4)
@@ -26,19 +29,49 @@ const (
 			team_name = EXCLUDED.team_name,
 			is_active = EXCLUDED.is_active
 	`
-	getTeamByNameQuery = `SELECT user_id, username, is_active FROM users WHERE team_name = $1`
+	handOffReviewQuery = `
+		WITH assigned AS (
+			SELECT prr.pull_request_id
+			FROM pr_reviewers prr
+			JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+			WHERE prr.pull_request_id = $1 AND prr.reviewer_id = $2 AND pr.status = $4
+		), inserted AS (
+			INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
+			SELECT pull_request_id, $3 FROM assigned
+			ON CONFLICT (pull_request_id, reviewer_id) DO NOTHING
+			RETURNING pull_request_id
+		), removed AS (
+			DELETE FROM pr_reviewers prr
+			USING inserted
+			WHERE prr.pull_request_id = inserted.pull_request_id AND prr.reviewer_id = $2
+		)
+		SELECT (SELECT COUNT(*) FROM assigned), (SELECT COUNT(*) FROM inserted)
+	`
+	deactivateUsersQuery     = `UPDATE users SET is_active = false WHERE user_id = ANY($1)`
+	getTeamByNameQuery       = `SELECT user_id, username, is_active FROM users WHERE team_name = $1`
+	getReviewCapacitiesQuery = `
+		SELECT user_id, max_open_reviews FROM users
+		WHERE user_id = ANY($1) AND max_open_reviews IS NOT NULL
+	`
+	getActiveUsersOutsideTeamQuery = `
+		SELECT user_id, team_name FROM users
+		WHERE is_active = true AND team_name IS NOT NULL AND team_name <> $1
+		ORDER BY team_name, user_id
+	`
 )
 
 type UserRepo struct {
-	db *pgx.Conn
+	db querier
 }
 
 func NewUserRepo(db *Postgres) *UserRepo {
-	return &UserRepo{db: db.conn}
+	return &UserRepo{db: db.pool}
 }
 
 func (r *UserRepo) GetUserReviews(ctx context.Context, userID string) ([]dto.PullRequestShortDTO, error) {
-	rows, err := r.db.Query(ctx, getUserReviewsQuery, userID)
+	defer metrics.ObserveDBQuery("user", "GetUserReviews", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getUserReviewsQuery, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении ревью пользователя: %v", err)
 	}
@@ -58,7 +91,9 @@ func (r *UserRepo) GetUserReviews(ctx context.Context, userID string) ([]dto.Pul
 }
 
 func (r *UserRepo) SetUserActive(ctx context.Context, userID string, isActive bool) (*dto.UserDTO, error) {
-	_, err := r.db.Exec(ctx, setUserActiveQuery, userID, isActive)
+	defer metrics.ObserveDBQuery("user", "SetUserActive", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, setUserActiveQuery, userID, isActive)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при обновлении статуса пользователя: %v", err)
 	}
@@ -66,17 +101,108 @@ func (r *UserRepo) SetUserActive(ctx context.Context, userID string, isActive bo
 	return r.GetUser(ctx, userID)
 }
 
+// DeactivateUsers выключает пользователей и применяет переназначения из
+// плана одним пакетом запросов. Атомарность обеспечивает вызывающий через
+// TxManager. Возвращает отчёт о применённом: старый ревьювер снимается только
+// вместе с назначением замены, поэтому PR, где замена уже ревьювер, попадает в
+// no_candidate, а переназначения, ставшие неактуальными (PR смерджен или
+// ревьювер уже снят), пропускаются
+func (r *UserRepo) DeactivateUsers(ctx context.Context, userIDs []string, plan dto.HandoffReportDTO) (*dto.HandoffReportDTO, error) {
+	defer metrics.ObserveDBQuery("user", "DeactivateUsers", time.Now())
+
+	db := conn(ctx, r.db)
+
+	if _, err := db.Exec(ctx, deactivateUsersQuery, userIDs); err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении статуса пользователей: %v", err)
+	}
+
+	report := &dto.HandoffReportDTO{
+		Reassigned:  make([]dto.ReviewHandoffDTO, 0, len(plan.Reassigned)),
+		NoCandidate: append([]dto.ReviewHandoffDTO{}, plan.NoCandidate...),
+	}
+	if len(plan.Reassigned) == 0 {
+		return report, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, h := range plan.Reassigned {
+		batch.Queue(handOffReviewQuery, h.PullRequestID, h.OldUserID, h.ReplacedBy, dto.StatusOpen)
+	}
+
+	results := db.SendBatch(ctx, batch)
+	for _, h := range plan.Reassigned {
+		var assigned, inserted int
+		if err := results.QueryRow().Scan(&assigned, &inserted); err != nil {
+			_ = results.Close()
+			return nil, fmt.Errorf("ошибка при переназначении ревьювера на PR %s: %v", h.PullRequestID, err)
+		}
+		switch {
+		case inserted > 0:
+			report.Reassigned = append(report.Reassigned, h)
+		case assigned > 0:
+			report.NoCandidate = append(report.NoCandidate, dto.ReviewHandoffDTO{
+				PullRequestID: h.PullRequestID,
+				OldUserID:     h.OldUserID,
+			})
+		}
+	}
+	if err := results.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка при переназначении ревьюверов: %v", err)
+	}
+
+	return report, nil
+}
+
 func (r *UserRepo) GetUser(ctx context.Context, userID string) (*dto.UserDTO, error) {
+	defer metrics.ObserveDBQuery("user", "GetUser", time.Now())
+
 	var user dto.UserDTO
-	err := r.db.QueryRow(ctx, getUserQuery, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+	err := conn(ctx, r.db).QueryRow(ctx, getUserQuery, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.MaxOpenReviews)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении пользователя: %v", err)
 	}
 	return &user, nil
 }
 
+func (r *UserRepo) SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (*dto.UserDTO, error) {
+	defer metrics.ObserveDBQuery("user", "SetMaxOpenReviews", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, setMaxOpenReviewsQuery, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении лимита ревью пользователя: %v", err)
+	}
+
+	return r.GetUser(ctx, userID)
+}
+
+// GetReviewCapacities возвращает личные лимиты открытых ревью; пользователей
+// без личного лимита в результате нет
+func (r *UserRepo) GetReviewCapacities(ctx context.Context, userIDs []string) (map[string]int, error) {
+	defer metrics.ObserveDBQuery("user", "GetReviewCapacities", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getReviewCapacitiesQuery, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении лимитов ревью: %v", err)
+	}
+	defer rows.Close()
+
+	capacities := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var limit int
+		if err := rows.Scan(&userID, &limit); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении лимита ревью: %v", err)
+		}
+		capacities[userID] = limit
+	}
+
+	return capacities, nil
+}
+
 func (r *UserRepo) CreateOrUpdateUser(ctx context.Context, member dto.TeamMemberDTO, teamName string) error {
-	_, err := r.db.Exec(ctx, createOrUpdateUserQuery, member.UserID, member.Username, teamName, member.IsActive)
+	defer metrics.ObserveDBQuery("user", "CreateOrUpdateUser", time.Now())
+
+	_, err := conn(ctx, r.db).Exec(ctx, createOrUpdateUserQuery, member.UserID, member.Username, teamName, member.IsActive)
 	if err != nil {
 		return fmt.Errorf("ошибка при создании/обновлении пользователя: %v", err)
 	}
@@ -84,7 +210,9 @@ func (r *UserRepo) CreateOrUpdateUser(ctx context.Context, member dto.TeamMember
 }
 
 func (r *UserRepo) GetTeamByName(ctx context.Context, teamName string) (*dto.TeamDTO, error) {
-	rows, err := r.db.Query(ctx, getTeamByNameQuery, teamName)
+	defer metrics.ObserveDBQuery("user", "GetTeamByName", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getTeamByNameQuery, teamName)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении команды: %v", err)
 	}
@@ -104,3 +232,26 @@ func (r *UserRepo) GetTeamByName(ctx context.Context, teamName string) (*dto.Tea
 		Members:  members,
 	}, nil
 }
+
+// GetActiveUsersOutsideTeam возвращает активных пользователей других команд,
+// упорядоченных по команде
+func (r *UserRepo) GetActiveUsersOutsideTeam(ctx context.Context, teamName string) ([]dto.UserDTO, error) {
+	defer metrics.ObserveDBQuery("user", "GetActiveUsersOutsideTeam", time.Now())
+
+	rows, err := conn(ctx, r.db).Query(ctx, getActiveUsersOutsideTeamQuery, teamName)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении пользователей других команд: %v", err)
+	}
+	defer rows.Close()
+
+	users := []dto.UserDTO{}
+	for rows.Next() {
+		user := dto.UserDTO{IsActive: true}
+		if err := rows.Scan(&user.UserID, &user.TeamName); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении пользователя: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
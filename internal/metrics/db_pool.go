@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"AvitoTech/internal/domain/dto"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dbPoolCollector отдаёт статистику пула соединений при каждом сборе метрик
+type dbPoolCollector struct {
+	stats func() dto.PoolStatsDTO
+
+	maxConns          *prometheus.Desc
+	totalConns        *prometheus.Desc
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	acquires          *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquires     *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+	newConns          *prometheus.Desc
+	destroyed         *prometheus.Desc
+}
+
+// NewDBPoolCollector создаёт коллектор статистики пула; stats вызывается
+// на каждый запрос /metrics
+func NewDBPoolCollector(stats func() dto.PoolStatsDTO) prometheus.Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, labels, nil)
+	}
+	return &dbPoolCollector{
+		stats:             stats,
+		maxConns:          desc("max_conns", "Maximum size of the connection pool."),
+		totalConns:        desc("total_conns", "Connections currently in the pool."),
+		acquiredConns:     desc("acquired_conns", "Connections currently acquired by queries."),
+		idleConns:         desc("idle_conns", "Idle connections in the pool."),
+		constructingConns: desc("constructing_conns", "Connections being established."),
+		acquires:          desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquires:     desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquires:  desc("canceled_acquires_total", "Acquires canceled by their context."),
+		newConns:          desc("new_conns_total", "Connections opened by the pool."),
+		destroyed:         desc("destroyed_conns_total", "Connections closed by the pool, by reason.", "reason"),
+	}
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.acquires
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquires
+	ch <- c.canceledAcquires
+	ch <- c.newConns
+	ch <- c.destroyed
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(s.ConstructingConns))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.AcquireCount))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, float64(s.AcquireDurationMs)/1000)
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount))
+	ch <- prometheus.MustNewConstMetric(c.newConns, prometheus.CounterValue, float64(s.NewConnsCount))
+	ch <- prometheus.MustNewConstMetric(c.destroyed, prometheus.CounterValue, float64(s.MaxLifetimeDestroyCount), "max_lifetime")
+	ch <- prometheus.MustNewConstMetric(c.destroyed, prometheus.CounterValue, float64(s.MaxIdleDestroyCount), "max_idle")
+}
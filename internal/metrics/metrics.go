@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pr_service"
+
+// Причины переназначения ревьювера
+const (
+	ReassignManual       = "manual"
+	ReassignDeactivation = "deactivation"
+	ReassignDeclined     = "declined"
+)
+
+// Операции, в которых может не найтись кандидата
+const (
+	OperationCreate   = "create"
+	OperationReassign = "reassign"
+	OperationHandoff  = "handoff"
+	OperationDecline  = "decline"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, chi route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_rate_limited_total",
+		Help:      "Requests rejected with 429 by rate limit (configured path or default).",
+	}, []string{"limit"})
+
+	prCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_created_total",
+		Help:      "Pull requests created.",
+	})
+
+	prMerged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_merged_total",
+		Help:      "Pull requests merged.",
+	})
+
+	prTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_request_transitions_total",
+		Help:      "Pull request status transitions by action (ready, close, reopen, merge).",
+	}, []string{"action"})
+
+	reviewsSubmitted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviews_submitted_total",
+		Help:      "Review verdicts submitted by assigned reviewers, by verdict.",
+	}, []string{"verdict"})
+
+	reviewersChanged = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewers_changed_total",
+		Help:      "Reviewers explicitly added to or removed from pull requests.",
+	}, []string{"action"})
+
+	reviewersReassigned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewers_reassigned_total",
+		Help:      "Reviewer replacements by reason (manual reassign or deactivation handoff).",
+	}, []string{"reason"})
+
+	noCandidate = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewer_no_candidate_total",
+		Help:      "Cases where no reviewer candidate was available, by operation.",
+	}, []string{"operation"})
+
+	prUnderstaffed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_understaffed_total",
+		Help:      "Pull requests opened with fewer reviewers than required because candidates were inactive, had declined or were at review capacity.",
+	})
+
+	assignedReviewers = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "assigned_reviewers",
+		Help:      "Number of reviewers assigned when a pull request is opened (created or marked ready).",
+		Buckets:   prometheus.LinearBuckets(0, 1, 11),
+	})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Repository method latency, including all queries the method runs.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		rateLimited,
+		prCreated,
+		prMerged,
+		prTransitions,
+		reviewsSubmitted,
+		reviewersChanged,
+		reviewersReassigned,
+		noCandidate,
+		prUnderstaffed,
+		assignedReviewers,
+		dbQueryDuration,
+	)
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Register добавляет сторонние коллекторы, например статистику пула БД
+func Register(c prometheus.Collector) error {
+	return registry.Register(c)
+}
+
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+func RateLimited(limit string) {
+	rateLimited.WithLabelValues(limit).Inc()
+}
+
+func PRCreated() {
+	prCreated.Inc()
+}
+
+// ReviewersAssigned - сколько ревьюверов получил PR при открытии
+func ReviewersAssigned(reviewers int) {
+	assignedReviewers.Observe(float64(reviewers))
+}
+
+func PRMerged() {
+	prMerged.Inc()
+	prTransitions.WithLabelValues("merge").Inc()
+}
+
+func PRTransition(action string) {
+	prTransitions.WithLabelValues(action).Inc()
+}
+
+func ReviewSubmitted(verdict string) {
+	reviewsSubmitted.WithLabelValues(verdict).Inc()
+}
+
+func ReviewerChanged(action string) {
+	reviewersChanged.WithLabelValues(action).Inc()
+}
+
+func ReviewersReassigned(reason string, count int) {
+	if count > 0 {
+		reviewersReassigned.WithLabelValues(reason).Add(float64(count))
+	}
+}
+
+func NoCandidate(operation string, count int) {
+	if count > 0 {
+		noCandidate.WithLabelValues(operation).Add(float64(count))
+	}
+}
+
+func PRUnderstaffed() {
+	prUnderstaffed.Inc()
+}
+
+// ObserveDBQuery вызывается через defer в начале метода репозитория:
+//
+//	defer metrics.ObserveDBQuery("pr", "GetPR", time.Now())
+func ObserveDBQuery(repository, method string, start time.Time) {
+	dbQueryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -31,3 +33,21 @@ func Sync() {
 		_ = Log.Sync()
 	}
 }
+
+type ctxKey struct{}
+
+// WithContext кладёт логгер в контекст, обычно дочерний с полями запроса
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext возвращает логгер запроса, а если его нет - глобальный Log
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+		return l
+	}
+	if Log != nil {
+		return Log
+	}
+	return zap.NewNop()
+}